@@ -32,5 +32,8 @@ func (a *AgentCmd) Run() error {
 }
 
 func (a *AgentCmd) AfterApply() error {
-	return a.AgentConfig.Db.AfterApply()
+	if err := a.AgentConfig.Db.AfterApply(); err != nil {
+		return err
+	}
+	return a.AgentConfig.Backup.AfterApply()
 }
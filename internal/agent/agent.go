@@ -20,14 +20,19 @@ func New(config *config.AgentConfig) *Agent {
 	signalServer := server.NewSignalServer()
 
 	dbStatusConsumer := server.NewBaseConsumer[*proto.Database]("Db Status Notifier", &grpc_agent.DbStatusSender{}, 1)
+	backupConsumer := server.NewBaseConsumer[server.NamedElement]("Backup Notifier", &grpc_agent.BackupSender{}, 1)
+	jobStatusConsumer := server.NewBaseConsumer[*proto.JobStatus]("Job Status Notifier", &grpc_agent.JobStatusSender{}, 1)
 
-	dbJobHandler := db_task.NewDbTaskHandler(&config.Db)
+	dbJobHandler := db_task.NewDbTaskHandler(&config.Db, &config.Backup, &config.Task)
 	dbJobConsumer := server.NewBaseConsumer[job.Task]("Db Job Handler", dbJobHandler, 4)
 
 	jobHandler := &job.JobHandler{}
 	jobConsumer := server.NewBaseConsumer[server.NamedElement]("Pending Job Handler", jobHandler, 1)
 
 	grpcAgentServer := grpc_agent.NewGrpcAgentServer(&config.Grpc, signalServer.QuitCtx)
+	idleDbReaper := grpc_agent.NewIdleDbReaper(&config.Db, signalServer.QuitCtx)
+	dailyBackupScheduler := grpc_agent.NewDailyBackupScheduler(&config.Backup, signalServer.QuitCtx)
+	baseBackupScheduler := grpc_agent.NewBaseBackupScheduler(&config.Backup, signalServer.QuitCtx)
 
 	agent := Agent{
 		Config: config,
@@ -36,9 +41,14 @@ func New(config *config.AgentConfig) *Agent {
 			Servers: []server.Server{
 				signalServer,
 				dbStatusConsumer,
+				backupConsumer,
+				jobStatusConsumer,
 				dbJobConsumer,
 				jobConsumer,
 				grpcAgentServer,
+				idleDbReaper,
+				dailyBackupScheduler,
+				baseBackupScheduler,
 			},
 			QuitCtx: signalServer.QuitCtx,
 			Quit:    signalServer.Quit,
@@ -46,6 +56,8 @@ func New(config *config.AgentConfig) *Agent {
 	}
 
 	agent.Set(constants.AgentKeyNotifyDbStatusProducer, dbStatusConsumer.Producer())
+	agent.Set(constants.AgentKeyNotifyBackupProducer, backupConsumer.Producer())
+	agent.Set(constants.AgentKeyNotifyJobStatusProducer, jobStatusConsumer.Producer())
 	agent.Set(constants.AgentKeyReadyToRunJobProducer, dbJobConsumer.Producer())
 	agent.Set(constants.AgentKeyJobProducer, jobConsumer.Producer())
 
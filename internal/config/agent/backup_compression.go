@@ -0,0 +1,29 @@
+package agent
+
+import "path/filepath"
+
+const (
+	BackupCompressionNone = "none"
+	BackupCompressionGzip = "gzip"
+	BackupCompressionZstd = "zstd"
+)
+
+var backupCompressionExts = map[string]string{
+	BackupCompressionGzip: ".gz",
+	BackupCompressionZstd: ".zst",
+}
+
+func BackupCompressionExt(compression string) string {
+	return backupCompressionExts[compression]
+}
+
+// BackupCompressionOf detects the compression of the backup by its extension
+func BackupCompressionOf(backupPath string) string {
+	ext := filepath.Ext(backupPath)
+	for compression, compressionExt := range backupCompressionExts {
+		if ext == compressionExt {
+			return compression
+		}
+	}
+	return BackupCompressionNone
+}
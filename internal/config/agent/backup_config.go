@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+type BackupConfig struct {
+	DailyEnabled bool `default:"false" negatable:"true" help:"Enable the daily backup of ready to use databases"`
+	// The daily backup is only started in the window,
+	// in format of `HH:MM` in the local time of the agent.
+	// The window can cross midnight, e.g. `22:00` to `02:00`
+	WindowStart string `default:"01:00" help:"The start of the daily backup window, in format of HH:MM"`
+	WindowEnd   string `default:"05:00" help:"The end of the daily backup window, in format of HH:MM"`
+	// How often to check the databases that need a daily backup.
+	CheckInterval time.Duration `default:"10m" help:"How often to check the databases that need a daily backup"`
+
+	// Only backup these databases, all databases are backed up if empty.
+	IncludeDbs []string `help:"Only backup these databases daily, all databases are backed up if empty"`
+	// Never backup these databases.
+	ExcludeDbs []string `help:"Databases that are never backed up daily"`
+
+	// The retention policy of the backups, the backups are pruned after the daily backup.
+	// The retention is disabled if all of them are 0.
+	KeepDaily   int           `default:"0" help:"How many daily backups to keep"`
+	KeepWeekly  int           `default:"0" help:"How many weekly backups to keep"`
+	KeepMonthly int           `default:"0" help:"How many monthly backups to keep"`
+	MaxAge      time.Duration `default:"0" help:"Prune the backups older than this, 0 means no limit"`
+
+	// The backups without a manifest are incomplete, or created before the manifest was introduced.
+	// They are refused to restore unless this is enabled, and their integrity is not checked.
+	AllowLegacyBackups bool `default:"false" help:"Restore the backups without a manifest, their integrity is not checked"`
+
+	Storage    StorageConfig          `embed:"" prefix:"storage-"`
+	Encryption BackupEncryptionConfig `embed:"" prefix:"encryption-"`
+	Pitr       PitrConfig             `embed:"" prefix:"pitr-"`
+
+	windowStart time.Time
+	windowEnd   time.Time
+}
+
+func (c *BackupConfig) AfterApply() error {
+	var err error
+	if c.windowStart, err = time.Parse("15:04", c.WindowStart); err != nil {
+		return fmt.Errorf("illegal backup window start: %w", err)
+	}
+	if c.windowEnd, err = time.Parse("15:04", c.WindowEnd); err != nil {
+		return fmt.Errorf("illegal backup window end: %w", err)
+	}
+	if err := c.Storage.AfterApply(); err != nil {
+		return err
+	}
+	return c.Encryption.AfterApply()
+}
+
+// CurrentWindow returns the start time of the backup window that now is in,
+// ok is false if now is out of the backup window.
+func (c *BackupConfig) CurrentWindow(now time.Time) (start time.Time, ok bool) {
+	start = c.at(now, c.windowStart)
+	end := c.at(now, c.windowEnd)
+
+	if !start.Before(end) {
+		// The window crosses midnight
+		if now.Before(end) {
+			return start.AddDate(0, 0, -1), true
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+
+	if now.Before(start) || !now.Before(end) {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+func (c *BackupConfig) at(day time.Time, clock time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(),
+		clock.Hour(), clock.Minute(), 0, 0, day.Location())
+}
+
+func (c *BackupConfig) ShouldDailyBackup(dbName string) bool {
+	if slices.Contains(c.ExcludeDbs, dbName) {
+		return false
+	}
+	return len(c.IncludeDbs) == 0 || slices.Contains(c.IncludeDbs, dbName)
+}
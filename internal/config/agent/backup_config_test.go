@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupConfig_CurrentWindow(t *testing.T) {
+	day := func(hour, min int) time.Time {
+		return time.Date(2024, 7, 10, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		start     string
+		end       string
+		now       time.Time
+		wantOk    bool
+		wantStart time.Time
+	}{
+		{"Before window", "01:00", "05:00", day(0, 30), false, time.Time{}},
+		{"In window", "01:00", "05:00", day(3, 0), true, day(1, 0)},
+		{"At window start", "01:00", "05:00", day(1, 0), true, day(1, 0)},
+		{"At window end", "01:00", "05:00", day(5, 0), false, time.Time{}},
+		{"Cross midnight before midnight", "22:00", "02:00", day(23, 0), true, day(22, 0)},
+		{"Cross midnight after midnight", "22:00", "02:00", day(1, 0), true, day(22, 0).AddDate(0, 0, -1)},
+		{"Cross midnight out of window", "22:00", "02:00", day(12, 0), false, time.Time{}},
+		{"Whole day", "03:00", "03:00", day(2, 0), true, day(3, 0).AddDate(0, 0, -1)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &BackupConfig{WindowStart: test.start, WindowEnd: test.end}
+			assert.NoError(t, config.AfterApply())
+
+			start, ok := config.CurrentWindow(test.now)
+			assert.Equal(t, test.wantOk, ok)
+			assert.Equal(t, test.wantStart, start)
+		})
+	}
+}
+
+func TestBackupConfig_AfterApply(t *testing.T) {
+	config := &BackupConfig{WindowStart: "1am", WindowEnd: "05:00"}
+	assert.Error(t, config.AfterApply())
+}
+
+func TestBackupConfig_ShouldDailyBackup(t *testing.T) {
+	config := &BackupConfig{}
+	assert.True(t, config.ShouldDailyBackup("app"))
+
+	config.ExcludeDbs = []string{"app"}
+	assert.False(t, config.ShouldDailyBackup("app"))
+	assert.True(t, config.ShouldDailyBackup("other"))
+
+	config.IncludeDbs = []string{"other"}
+	assert.True(t, config.ShouldDailyBackup("other"))
+	assert.False(t, config.ShouldDailyBackup("another"))
+}
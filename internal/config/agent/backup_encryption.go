@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"filippo.io/age"
+)
+
+const BackupEncryptionAge = "age"
+
+type BackupEncryptionConfig struct {
+	// The age public key to encrypt the backups,
+	// the backups are not encrypted if empty.
+	Recipient string `env:"PG_HELPER_BACKUP_RECIPIENT" help:"The age public key to encrypt the backups"`
+	// The age identity file to decrypt the backups, it is only read on restore.
+	// Keep the old identities in the file after rotating the key,
+	// so that the old backups can still be restored.
+	IdentityFile string `env:"PG_HELPER_BACKUP_IDENTITY_FILE" help:"The age identity file to decrypt the backups"`
+	// The directory to stage the unencrypted dumps before encrypting, and the backups after decrypting.
+	// It should be private to the agent, unlike BackupRootPath which is shared between the agents.
+	// The system temp dir is used if empty.
+	TmpDir string `help:"The private directory to stage the unencrypted backups, the system temp dir if empty"`
+
+	recipient *age.X25519Recipient
+}
+
+func (c *BackupEncryptionConfig) AfterApply() error {
+	if c.Recipient == "" {
+		return nil
+	}
+
+	recipient, err := age.ParseX25519Recipient(c.Recipient)
+	if err != nil {
+		return fmt.Errorf("illegal backup recipient: %w", err)
+	}
+	c.recipient = recipient
+	return nil
+}
+
+func (c *BackupEncryptionConfig) Enabled() bool {
+	return c.recipient != nil
+}
+
+func (c *BackupEncryptionConfig) AgeRecipient() age.Recipient {
+	return c.recipient
+}
+
+// Fingerprint of the public key that encrypts the new backups
+func (c *BackupEncryptionConfig) Fingerprint() string {
+	if c.recipient == nil {
+		return ""
+	}
+	return KeyFingerprint(c.recipient.String())
+}
+
+// Identity finds the identity in the IdentityFile that matches the fingerprint
+func (c *BackupEncryptionConfig) Identity(fingerprint string) (age.Identity, error) {
+	if c.IdentityFile == "" {
+		return nil, fmt.Errorf("no backup identity file provided")
+	}
+
+	f, err := os.Open(c.IdentityFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, identity := range identities {
+		x25519, ok := identity.(*age.X25519Identity)
+		if ok && KeyFingerprint(x25519.Recipient().String()) == fingerprint {
+			return identity, nil
+		}
+	}
+	return nil, fmt.Errorf("no backup identity matches the key fingerprint %s", fingerprint)
+}
+
+// KeyFingerprint returns the fingerprint of the public key,
+// in the format of `SHA256:<base64>` like the ssh key fingerprint.
+func KeyFingerprint(publicKey string) string {
+	sum := sha256.Sum256([]byte(publicKey))
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupEncryptionConfig_Identity(t *testing.T) {
+	oldKey, err := age.GenerateX25519Identity()
+	assert.NoError(t, err)
+	newKey, err := age.GenerateX25519Identity()
+	assert.NoError(t, err)
+
+	identityFile := filepath.Join(t.TempDir(), "identities.txt")
+	content := "# old key\n" + oldKey.String() + "\n" + newKey.String() + "\n"
+	assert.NoError(t, os.WriteFile(identityFile, []byte(content), 0600))
+
+	config := &BackupEncryptionConfig{Recipient: newKey.Recipient().String(), IdentityFile: identityFile}
+	assert.NoError(t, config.AfterApply())
+	assert.True(t, config.Enabled())
+
+	identity, err := config.Identity(config.Fingerprint())
+	assert.NoError(t, err)
+	assert.Equal(t, newKey.String(), identity.(*age.X25519Identity).String())
+
+	// The backups encrypted before rotating the key
+	identity, err = config.Identity(KeyFingerprint(oldKey.Recipient().String()))
+	assert.NoError(t, err)
+	assert.Equal(t, oldKey.String(), identity.(*age.X25519Identity).String())
+
+	_, err = config.Identity("SHA256:unknown")
+	assert.Error(t, err)
+}
+
+func TestBackupEncryptionConfig_Disabled(t *testing.T) {
+	config := &BackupEncryptionConfig{}
+	assert.NoError(t, config.AfterApply())
+	assert.False(t, config.Enabled())
+	assert.Equal(t, "", config.Fingerprint())
+
+	config.Recipient = "not-a-key"
+	assert.Error(t, config.AfterApply())
+}
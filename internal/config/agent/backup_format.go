@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Plain SQL, restored by psql
+	BackupFormatPlain = "plain"
+	// pg_dump custom format (-Fc), restored by pg_restore
+	BackupFormatCustom = "custom"
+	// pg_dump directory format (-Fd), the only format supports parallel dump
+	BackupFormatDirectory = "directory"
+)
+
+var backupFormatExts = map[string]string{
+	BackupFormatPlain:     ".sql",
+	BackupFormatCustom:    ".dump",
+	BackupFormatDirectory: ".dir",
+}
+
+func BackupFormatExt(format string) string {
+	if ext, ok := backupFormatExts[format]; ok {
+		return ext
+	}
+	return backupFormatExts[BackupFormatPlain]
+}
+
+// BackupFormatOf detects the format of the backup by its extension,
+// the backups without a known extension are treated as plain SQL.
+func BackupFormatOf(backupPath string) string {
+	backupPath = strings.TrimSuffix(backupPath, "/")
+	backupPath = strings.TrimSuffix(backupPath, BackupCompressionExt(BackupCompressionOf(backupPath)))
+	ext := filepath.Ext(backupPath)
+	for format, formatExt := range backupFormatExts {
+		if ext == formatExt {
+			return format
+		}
+	}
+	return BackupFormatPlain
+}
@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupFormatOf(t *testing.T) {
+	assert.Equal(t, BackupFormatPlain, BackupFormatOf("pg-16/app/2024-07-10_01:00:00.sql"))
+	assert.Equal(t, BackupFormatCustom, BackupFormatOf("pg-16/app/2024-07-10_01:00:00.dump"))
+	assert.Equal(t, BackupFormatDirectory, BackupFormatOf("pg-16/app/2024-07-10_01:00:00.dir/"))
+	assert.Equal(t, BackupFormatPlain, BackupFormatOf("pg-16/app/backup"))
+	assert.Equal(t, BackupFormatPlain, BackupFormatOf("pg-16/app/2024-07-10_01:00:00.sql.zst"))
+}
+
+func TestBackupCompressionOf(t *testing.T) {
+	assert.Equal(t, BackupCompressionGzip, BackupCompressionOf("pg-16/app/2024-07-10_01:00:00.sql.gz"))
+	assert.Equal(t, BackupCompressionZstd, BackupCompressionOf("pg-16/app/2024-07-10_01:00:00.sql.zst"))
+	assert.Equal(t, BackupCompressionNone, BackupCompressionOf("pg-16/app/2024-07-10_01:00:00.sql"))
+}
+
+func TestDbConfig_NewBackupFile(t *testing.T) {
+	config := &DbConfig{CurrentVersion: 16, BackupFormat: BackupFormatCustom}
+	backupPath := config.NewBackupFile("app")
+	assert.Equal(t, BackupFormatCustom, BackupFormatOf(backupPath))
+
+	_, err := config.ValidateBackupPath(backupPath, "app", nil)
+	assert.NoError(t, err)
+
+	config = &DbConfig{CurrentVersion: 16, BackupFormat: BackupFormatPlain, BackupCompression: BackupCompressionGzip}
+	assert.Equal(t, BackupCompressionGzip, BackupCompressionOf(config.NewBackupFile("app")))
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The manifest is saved beside the backup as `<BackupPath>.manifest.json`
+const BackupManifestExt = ".manifest.json"
+
+type BackupManifest struct {
+	BackupPath  string `json:"backup_path"`
+	DbName      string `json:"db_name"`
+	Format      string `json:"format"`
+	Compression string `json:"compression"`
+	// The backup is encrypted if Encryption is not empty,
+	// KeyFingerprint is the fingerprint of the public key that encrypts it.
+	Encryption     string `json:"encryption,omitempty"`
+	KeyFingerprint string `json:"key_fingerprint,omitempty"`
+
+	// The total size of the backup files
+	Size int64 `json:"size"`
+	// The SHA-256 of the backup file, for a backup in directory format,
+	// it is calculated from the relative path and the content of each file in order.
+	Sha256 string `json:"sha256"`
+
+	PgDumpVersion  string    `json:"pg_dump_version"`
+	SourceInstance string    `json:"source_instance"`
+	PgMajor        int32     `json:"pg_major"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func BackupManifestPath(backupPath string) string {
+	return backupPath + BackupManifestExt
+}
+
+// BackupChecksum calculates the size and SHA-256 of the local backup file or directory
+func BackupChecksum(localPath string) (size int64, checksum string, err error) {
+	hash := sha256.New()
+	err = filepath.WalkDir(localPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(localPath, p)
+		if err != nil {
+			return err
+		}
+		if rel != "." {
+			hash.Write([]byte(filepath.ToSlash(rel) + "\n"))
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		n, err := io.Copy(hash, f)
+		size += n
+		return err
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Verify checks the local backup against the manifest
+func (m *BackupManifest) Verify(localPath string) error {
+	size, checksum, err := BackupChecksum(localPath)
+	if err != nil {
+		return err
+	}
+	if size != m.Size {
+		return fmt.Errorf("backup size not match, expect %d, got %d", m.Size, size)
+	}
+	if checksum != m.Sha256 {
+		return fmt.Errorf("backup checksum not match")
+	}
+	return nil
+}
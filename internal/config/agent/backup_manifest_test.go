@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupManifest_Verify(t *testing.T) {
+	backup := filepath.Join(t.TempDir(), "backup.sql")
+	assert.NoError(t, os.WriteFile(backup, []byte("CREATE TABLE t (id int);\n"), 0640))
+
+	size, checksum, err := BackupChecksum(backup)
+	assert.NoError(t, err)
+	manifest := &BackupManifest{Size: size, Sha256: checksum}
+	assert.NoError(t, manifest.Verify(backup))
+
+	// Truncated backup
+	assert.NoError(t, os.WriteFile(backup, []byte("CREATE TABLE"), 0640))
+	assert.Error(t, manifest.Verify(backup))
+}
+
+func TestBackupChecksum_Directory(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "toc.dat"), []byte("toc"), 0640))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "3001.dat.gz"), []byte("data"), 0640))
+
+	size, checksum, err := BackupChecksum(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), size)
+
+	assert.NoError(t, os.Rename(filepath.Join(dir, "3001.dat.gz"), filepath.Join(dir, "3002.dat.gz")))
+	_, renamed, err := BackupChecksum(dir)
+	assert.NoError(t, err)
+	assert.NotEqual(t, checksum, renamed)
+}
+
+func TestDbConfig_ValidateBackupPath_Manifest(t *testing.T) {
+	config := &DbConfig{CurrentVersion: 16}
+	backupPath := "pg-16/app/2024-07-10_01:00:00.sql"
+	manifest := &BackupManifest{BackupPath: backupPath, DbName: "app", PgMajor: 16}
+
+	_, err := config.ValidateBackupPath(backupPath, "app", manifest)
+	assert.NoError(t, err)
+
+	manifest.PgMajor = 15
+	_, err = config.ValidateBackupPath(backupPath, "app", manifest)
+	assert.Error(t, err)
+
+	manifest.PgMajor = 16
+	manifest.BackupPath = "pg-16/app/2024-07-11_01:00:00.sql"
+	_, err = config.ValidateBackupPath(backupPath, "app", manifest)
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type BackupFile struct {
+	// The path relative to DbConfig.BackupRootPath
+	Path      string
+	CreatedAt time.Time
+}
+
+func (c *BackupConfig) RetentionEnabled() bool {
+	return c.KeepDaily > 0 || c.KeepWeekly > 0 || c.KeepMonthly > 0 || c.MaxAge > 0
+}
+
+// BackupsToPrune returns the backups that are not kept by the retention policy.
+//
+// A backup is kept if it is the newest backup of one of the last KeepDaily days,
+// KeepWeekly weeks or KeepMonthly months, and it is not older than MaxAge.
+// The newest backup is always kept.
+func (c *BackupConfig) BackupsToPrune(backups []BackupFile, now time.Time) []BackupFile {
+	if !c.RetentionEnabled() || len(backups) == 0 {
+		return nil
+	}
+
+	sorted := make([]BackupFile, len(backups))
+	copy(sorted, backups)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	gfsEnabled := c.KeepDaily > 0 || c.KeepWeekly > 0 || c.KeepMonthly > 0
+	keep := make(map[string]bool)
+	if gfsEnabled {
+		keepNewestOfPeriod(sorted, keep, c.KeepDaily, func(t time.Time) string {
+			return t.Format("2006-01-02")
+		})
+		keepNewestOfPeriod(sorted, keep, c.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		})
+		keepNewestOfPeriod(sorted, keep, c.KeepMonthly, func(t time.Time) string {
+			return t.Format("2006-01")
+		})
+	}
+
+	var pruned []BackupFile
+	for i, backup := range sorted {
+		if i == 0 {
+			continue
+		}
+
+		kept := !gfsEnabled || keep[backup.Path]
+		if c.MaxAge > 0 && now.Sub(backup.CreatedAt) > c.MaxAge {
+			kept = false
+		}
+		if !kept {
+			pruned = append(pruned, backup)
+		}
+	}
+	return pruned
+}
+
+// backups must be sorted by CreatedAt desc
+func keepNewestOfPeriod(backups []BackupFile, keep map[string]bool, count int, period func(time.Time) string) {
+	lastPeriod := ""
+	for _, backup := range backups {
+		if count <= 0 {
+			return
+		}
+		p := period(backup.CreatedAt)
+		if p == lastPeriod {
+			continue
+		}
+		lastPeriod = p
+		keep[backup.Path] = true
+		count--
+	}
+}
@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupConfig_BackupsToPrune(t *testing.T) {
+	now := time.Date(2024, 7, 31, 12, 0, 0, 0, time.UTC)
+
+	// Two backups per day in the last 60 days
+	var backups []BackupFile
+	for i := 0; i < 120; i++ {
+		createdAt := now.Add(-time.Duration(i) * 12 * time.Hour)
+		backups = append(backups, BackupFile{
+			Path:      createdAt.Format("2006-01-02_15:04:05") + ".sql",
+			CreatedAt: createdAt,
+		})
+	}
+
+	prunedPaths := func(pruned []BackupFile) map[string]bool {
+		paths := make(map[string]bool)
+		for _, backup := range pruned {
+			paths[backup.Path] = true
+		}
+		return paths
+	}
+
+	t.Run("Retention disabled", func(t *testing.T) {
+		config := &BackupConfig{}
+		assert.Empty(t, config.BackupsToPrune(backups, now))
+	})
+
+	t.Run("Keep daily", func(t *testing.T) {
+		config := &BackupConfig{KeepDaily: 3}
+		pruned := config.BackupsToPrune(backups, now)
+		assert.Len(t, pruned, len(backups)-3)
+
+		paths := prunedPaths(pruned)
+		assert.False(t, paths["2024-07-31_12:00:00.sql"])
+		assert.False(t, paths["2024-07-30_12:00:00.sql"])
+		assert.False(t, paths["2024-07-29_12:00:00.sql"])
+		assert.True(t, paths["2024-07-31_00:00:00.sql"])
+	})
+
+	t.Run("Keep daily weekly and monthly", func(t *testing.T) {
+		config := &BackupConfig{KeepDaily: 2, KeepWeekly: 2, KeepMonthly: 2}
+		pruned := config.BackupsToPrune(backups, now)
+
+		paths := prunedPaths(pruned)
+		// daily
+		assert.False(t, paths["2024-07-31_12:00:00.sql"])
+		assert.False(t, paths["2024-07-30_12:00:00.sql"])
+		// weekly, 2024-07-28 is the last Sunday of the previous ISO week
+		assert.False(t, paths["2024-07-28_12:00:00.sql"])
+		// monthly
+		assert.False(t, paths["2024-06-30_12:00:00.sql"])
+		assert.True(t, paths["2024-07-29_12:00:00.sql"])
+		assert.Len(t, pruned, len(backups)-4)
+	})
+
+	t.Run("Max age only", func(t *testing.T) {
+		config := &BackupConfig{MaxAge: 24 * time.Hour}
+		pruned := config.BackupsToPrune(backups, now)
+		assert.Len(t, pruned, len(backups)-3)
+	})
+
+	t.Run("Max age overrides GFS", func(t *testing.T) {
+		config := &BackupConfig{KeepMonthly: 3, MaxAge: 7 * 24 * time.Hour}
+		pruned := config.BackupsToPrune(backups, now)
+		paths := prunedPaths(pruned)
+		assert.False(t, paths["2024-07-31_12:00:00.sql"])
+		assert.True(t, paths["2024-06-30_12:00:00.sql"])
+	})
+
+	t.Run("Always keep the newest", func(t *testing.T) {
+		config := &BackupConfig{MaxAge: time.Hour}
+		old := []BackupFile{backups[len(backups)-1], backups[len(backups)-2]}
+		pruned := config.BackupsToPrune(old, now)
+		assert.Equal(t, []BackupFile{backups[len(backups)-1]}, pruned)
+	})
+}
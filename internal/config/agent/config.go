@@ -3,4 +3,7 @@ package agent
 type AgentConfig struct {
 	Db   DbConfig         `embed:"" prefix:"db-" group:"db"`
 	Grpc GrpcClientConfig `embed:"" prefix:"grpc-" group:"grpc"`
+
+	Backup BackupConfig `embed:"" prefix:"backup-" group:"backup"`
+	Task   TaskConfig   `embed:"" prefix:"task-" group:"task"`
 }
@@ -37,9 +37,35 @@ type DbConfig struct {
 
 	// The path of the database backups.
 	BackupRootPath string `default:"/var/lib/pg-helper/backups"`
+	// The format of the new backups, one of plain, custom, directory
+	BackupFormat string `default:"plain" enum:"plain,custom,directory" help:"The format of the backups, one of plain, custom, directory"`
+	// The compression of the plain SQL backups, one of none, gzip, zstd,
+	// the custom and directory formats are compressed by pg_dump already.
+	BackupCompression string `default:"none" enum:"none,gzip,zstd" help:"The compression of the plain backups, one of none, gzip, zstd"`
+	// The parallel jobs of pg_restore,
+	// pg_dump only runs in parallel with the directory format.
+	BackupJobs int `default:"1" help:"The parallel jobs to dump and restore the database"`
 	// The majar version of the database that pg-helper work with.
 	CurrentVersion int32 `env:"PG_MAJOR"`
 
+	// How long an idle database is kept before it is dropped,
+	// 0 means keep the idle database forever.
+	DurationToDropIdleDb time.Duration `default:"720h" help:"How long to keep an idle database before dropping it"`
+	// How often to check the expired idle databases.
+	IdleDbCheckInterval time.Duration `default:"1h" help:"How often to check the expired idle databases"`
+	// Drop the owner of the database too when dropping an expired idle database.
+	DropIdleDbOwner bool `default:"false" help:"Drop the owner together with the expired idle database"`
+
+	// The replication lag in bytes that is small enough to cutover
+	// when migrating a database by logical replication.
+	ReplicationMaxLag int64 `default:"1048576" help:"The replication lag in bytes that is small enough to cutover"`
+	// How often to check the replication progress.
+	ReplicationCheckInterval time.Duration `default:"10s" help:"How often to check the replication progress"`
+	// How long to wait for the initial data copy and the lag to catch up.
+	ReplicationTimeout time.Duration `default:"24h" help:"How long to wait for the replication to catch up"`
+	// How long the old database may refuse connections during the cutover.
+	CutoverTimeout time.Duration `default:"5m" help:"How long to wait for the last changes to be replicated during the cutover"`
+
 	tmpl *template.Template
 }
 
@@ -76,10 +102,15 @@ func (c *DbConfig) Host(info *InstanceInfo) string {
 }
 
 func (c *DbConfig) Url(dbName string, info *InstanceInfo) string {
+	return c.UrlAs(c.User, c.Password, dbName, info)
+}
+
+// UrlAs returns the url to connect to the database as another user
+func (c *DbConfig) UrlAs(user string, password string, dbName string, info *InstanceInfo) string {
 	if dbName == "" {
 		dbName = c.Name
 	}
-	return fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?sslmode=disable", c.User, url.QueryEscape(c.Password), c.Host(info), c.Port, dbName)
+	return fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?sslmode=disable", user, url.QueryEscape(password), c.Host(info), c.Port, dbName)
 }
 
 func (c *DbConfig) setPassword() error {
@@ -138,12 +169,37 @@ func (c *DbConfig) NewPoolConfig() *pgxpool.Config {
 }
 
 func (c *DbConfig) BackupDbDir(dbName string) string {
-	return fmt.Sprintf("%s/pg-%d/%s", c.BackupRootPath, c.CurrentVersion, dbName)
+	return filepath.Join(c.BackupRootPath, c.BackupDbRelDir(dbName))
 }
 
+const backupTimeFormat = "2006-01-02_15:04:05"
+
 // The backup file is relative to the BackupRootPath
 func (c *DbConfig) NewBackupFile(dbName string) string {
-	return fmt.Sprintf("pg-%d/%s/%s.sql", c.CurrentVersion, dbName, time.Now().Format("2006-01-02_15:04:05"))
+	ext := BackupFormatExt(c.BackupFormat)
+	if BackupFormatOf(ext) == BackupFormatPlain {
+		ext += BackupCompressionExt(c.BackupCompression)
+	}
+	return fmt.Sprintf("pg-%d/%s/%s%s", c.CurrentVersion, dbName, time.Now().Format(backupTimeFormat), ext)
+}
+
+// The base backup dir of the instance, relative to the PitrConfig.BaseBackupRootPath
+func (c *DbConfig) NewBaseBackupDir() string {
+	return fmt.Sprintf("%s/%s", c.InstanceName, time.Now().Format(backupTimeFormat))
+}
+
+// The backup dir of the database, relative to the BackupRootPath
+func (c *DbConfig) BackupDbRelDir(dbName string) string {
+	return fmt.Sprintf("pg-%d/%s", c.CurrentVersion, dbName)
+}
+
+// Parse the time when the backup is created from the backup file name
+func (c *DbConfig) BackupCreatedAt(backupPath string) (time.Time, error) {
+	name := filepath.Base(backupPath)
+	if len(name) < len(backupTimeFormat) {
+		return time.Time{}, fmt.Errorf("illegal backup file name")
+	}
+	return time.ParseInLocation(backupTimeFormat, name[:len(backupTimeFormat)], time.Local)
 }
 
 func (c *DbConfig) extractBackupPath(backupPath string) (dbName string, pgVersion int32, err error) {
@@ -165,7 +221,12 @@ func (c *DbConfig) extractBackupPath(backupPath string) (dbName string, pgVersio
 	return
 }
 
-func (c *DbConfig) ValidateBackupPath(backupPath string, dbName string) (pgVersionInPath int32, err error) {
+// ValidateBackupPath checks the backup path is taken from the database dbName,
+// the manifest is checked too if the backup has one.
+//
+// The backup can be restored to another database when cloning,
+// so dbName is the source database rather than the database to restore to.
+func (c *DbConfig) ValidateBackupPath(backupPath string, dbName string, manifest *BackupManifest) (pgVersionInPath int32, err error) {
 	dbNameInPath, pgVersionInPath, err := c.extractBackupPath(backupPath)
 	if err != nil {
 		return
@@ -181,6 +242,15 @@ func (c *DbConfig) ValidateBackupPath(backupPath string, dbName string) (pgVersi
 		return
 	}
 
+	if manifest != nil {
+		if filepath.Clean(manifest.BackupPath) != filepath.Clean(backupPath) ||
+			manifest.DbName != dbNameInPath ||
+			manifest.PgMajor != pgVersionInPath {
+			err = fmt.Errorf("illegal backup, manifest not match")
+			return
+		}
+	}
+
 	return
 }
 
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type PitrConfig struct {
+	// Take the base backups of the instance and manage the WAL archive.
+	Enabled bool `default:"false" negatable:"true" help:"Enable the point-in-time recovery with base backups and WAL archive"`
+	// The path of the base backups, each base backup is a data directory in plain format.
+	BaseBackupRootPath string `default:"/var/lib/pg-helper/base-backups" help:"The path of the base backups"`
+	// The WAL archive of the instance, the instance should archive to it,
+	// e.g. `archive_command = 'test ! -f /var/lib/pg-helper/wal-archive/%f && cp %p /var/lib/pg-helper/wal-archive/%f'`
+	WalArchivePath string `default:"/var/lib/pg-helper/wal-archive" help:"The WAL archive path that the instance archives to"`
+	// How often to take a base backup, 0 means only take base backups on request.
+	BaseBackupInterval time.Duration `default:"24h" help:"How often to take a base backup, 0 means on request only"`
+	// The base backups that are kept, the WAL segments older
+	// than the oldest kept base backup are pruned.
+	KeepBaseBackups int `default:"2" help:"How many base backups to keep"`
+	// The point-in-time recoveries are only prepared in the directories under it,
+	// the data dir of a restore request is relative to it.
+	RestoreRootPath string `default:"/var/lib/pg-helper/pitr-restores" help:"The path that the point-in-time recoveries are prepared in"`
+}
+
+// RestoreDataDir returns the data directory to prepare the recovery in,
+// it must be a sub directory of RestoreRootPath.
+func (c *PitrConfig) RestoreDataDir(dataDir string) (string, error) {
+	root := filepath.Clean(c.RestoreRootPath)
+	dir := filepath.Clean(dataDir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("data dir must be under %s", root)
+	}
+	return dir, nil
+}
+
+var walFileNamePattern = regexp.MustCompile(`^[0-9A-F]{24}`)
+
+// The information in the backup_label of a base backup
+type BackupLabel struct {
+	StartWalLocation string
+	// The WAL segment that the recovery starts from
+	StartWalFile string
+}
+
+var startWalPattern = regexp.MustCompile(`^START WAL LOCATION: (\S+) \(file ([0-9A-F]{24})\)$`)
+
+// ParseBackupLabel parses the backup_label written by pg_basebackup
+func ParseBackupLabel(content string) (*BackupLabel, error) {
+	label := &BackupLabel{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := startWalPattern.FindStringSubmatch(line); m != nil {
+			label.StartWalLocation = m[1]
+			label.StartWalFile = m[2]
+			break
+		}
+	}
+	if label.StartWalFile == "" {
+		return nil, fmt.Errorf("no start wal location in backup label")
+	}
+	return label, nil
+}
+
+// The WAL range in the backup_manifest of a base backup,
+// the base backup is consistent after the WAL up to StopWalLocation is replayed.
+type WalRange struct {
+	Timeline         int32
+	StartWalLocation string
+	StopWalLocation  string
+}
+
+type backupManifest struct {
+	WalRanges []struct {
+		Timeline int32  `json:"Timeline"`
+		StartLsn string `json:"Start-LSN"`
+		EndLsn   string `json:"End-LSN"`
+	} `json:"WAL-Ranges"`
+}
+
+// ParseBackupManifest returns the WAL range in the backup_manifest written by pg_basebackup,
+// the range of the latest timeline is returned if the backup spans several timelines.
+func ParseBackupManifest(content []byte) (*WalRange, error) {
+	var manifest backupManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+	if len(manifest.WalRanges) == 0 {
+		return nil, fmt.Errorf("no wal range in backup manifest")
+	}
+
+	latest := manifest.WalRanges[0]
+	for _, r := range manifest.WalRanges[1:] {
+		if r.Timeline > latest.Timeline {
+			latest = r
+		}
+	}
+	return &WalRange{
+		Timeline:         latest.Timeline,
+		StartWalLocation: latest.StartLsn,
+		StopWalLocation:  latest.EndLsn,
+	}, nil
+}
+
+// IsWalSegment reports whether the file in the WAL archive is a complete WAL segment
+func IsWalSegment(name string) bool {
+	return len(name) == 24 && walFileNamePattern.MatchString(name)
+}
+
+// WalFilesToPrune returns the files in the WAL archive that are not needed
+// by the base backup starting from startWalFile, the timeline history files are always kept.
+func WalFilesToPrune(names []string, startWalFile string) []string {
+	var pruned []string
+	for _, name := range names {
+		if IsWalFile(name) && name[:24] < startWalFile {
+			pruned = append(pruned, name)
+		}
+	}
+	return pruned
+}
+
+// IsWalFile reports whether the file in the WAL archive is a WAL segment or its backup history
+func IsWalFile(name string) bool {
+	return walFileNamePattern.MatchString(name) && !strings.HasSuffix(name, ".history")
+}
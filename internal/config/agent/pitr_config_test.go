@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBackupLabel(t *testing.T) {
+	content := `START WAL LOCATION: 0/2000028 (file 000000010000000000000002)
+CHECKPOINT LOCATION: 0/2000060
+BACKUP METHOD: streamed
+BACKUP FROM: primary
+START TIME: 2024-07-10 01:00:00 UTC
+LABEL: pg_basebackup base backup
+START TIMELINE: 1
+`
+	label, err := ParseBackupLabel(content)
+	assert.NoError(t, err)
+	assert.Equal(t, "0/2000028", label.StartWalLocation)
+	assert.Equal(t, "000000010000000000000002", label.StartWalFile)
+
+	_, err = ParseBackupLabel("LABEL: pg_basebackup base backup\n")
+	assert.Error(t, err)
+}
+
+func TestParseBackupManifest(t *testing.T) {
+	content := `{ "PostgreSQL-Backup-Manifest-Version": 1,
+"Files": [
+{ "Path": "backup_label", "Size": 225, "Last-Modified": "2024-07-10 01:00:00 GMT", "Checksum-Algorithm": "CRC32C", "Checksum": "c4f1d3a2" }
+],
+"WAL-Ranges": [
+{ "Timeline": 1, "Start-LSN": "0/2000028", "End-LSN": "0/2000100" },
+{ "Timeline": 2, "Start-LSN": "0/3000000", "End-LSN": "0/3000138" }
+],
+"Manifest-Checksum": "d2a1c0f9"}
+`
+	walRange, err := ParseBackupManifest([]byte(content))
+	assert.NoError(t, err)
+	assert.Equal(t, &WalRange{
+		Timeline:         2,
+		StartWalLocation: "0/3000000",
+		StopWalLocation:  "0/3000138",
+	}, walRange)
+
+	_, err = ParseBackupManifest([]byte(`{"PostgreSQL-Backup-Manifest-Version": 1, "Files": []}`))
+	assert.Error(t, err)
+
+	_, err = ParseBackupManifest([]byte("not a manifest"))
+	assert.Error(t, err)
+}
+
+func TestIsWalSegment(t *testing.T) {
+	assert.True(t, IsWalSegment("000000010000000000000002"))
+	assert.False(t, IsWalSegment("000000010000000000000002.00000028.backup"))
+	assert.False(t, IsWalSegment("000000020000000000000003.partial"))
+	assert.False(t, IsWalSegment("00000002.history"))
+	assert.False(t, IsWalSegment("archive_status"))
+}
+
+func TestWalFilesToPrune(t *testing.T) {
+	names := []string{
+		"000000010000000000000001",
+		"000000010000000000000002.00000028.backup",
+		"000000010000000000000002",
+		"000000010000000000000003",
+		"00000002.history",
+		"000000020000000000000003.partial",
+		"archive_status",
+	}
+
+	assert.Equal(t, []string{"000000010000000000000001"},
+		WalFilesToPrune(names, "000000010000000000000002"))
+	assert.Equal(t, []string{
+		"000000010000000000000001",
+		"000000010000000000000002.00000028.backup",
+		"000000010000000000000002",
+		"000000010000000000000003",
+	}, WalFilesToPrune(names, "000000020000000000000003"))
+}
+
+func TestRestoreDataDir(t *testing.T) {
+	c := &PitrConfig{RestoreRootPath: "/var/lib/pg-helper/pitr-restores/"}
+
+	dir, err := c.RestoreDataDir("pg16")
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/lib/pg-helper/pitr-restores/pg16", dir)
+
+	dir, err = c.RestoreDataDir("/var/lib/pg-helper/pitr-restores/pg16/../pg17")
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/lib/pg-helper/pitr-restores/pg17", dir)
+
+	for _, dataDir := range []string{
+		"",
+		".",
+		"..",
+		"../base-backups",
+		"/var/lib/pg-helper/pitr-restores",
+		"/var/lib/pg-helper/pitr-restores-other/pg16",
+		"/var/lib/postgresql/data",
+		"/var/lib/pg-helper/pitr-restores/../../postgresql",
+	} {
+		_, err := c.RestoreDataDir(dataDir)
+		assert.Error(t, err, dataDir)
+	}
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	StorageTypeLocal = "local"
+	StorageTypeS3    = "s3"
+)
+
+type StorageConfig struct {
+	// Where the backups are stored, the backups are always staged
+	// in DbConfig.BackupRootPath before uploading to a remote storage.
+	Type string `default:"local" enum:"local,s3" help:"The storage of the backups, one of local, s3"`
+
+	S3 S3StorageConfig `embed:"" prefix:"s3-"`
+}
+
+type S3StorageConfig struct {
+	// The endpoint of the S3 compatible storage, e.g. `minio:9000`
+	Endpoint string `help:"The endpoint of the S3 compatible storage"`
+	Region   string `help:"The region of the bucket"`
+	Bucket   string `help:"The bucket to save the backups"`
+	// The prefix of the object keys, the key of a backup is `<Prefix>/<BackupPath>`
+	Prefix string `help:"The prefix of the backup keys in the bucket"`
+
+	AccessKey string `env:"PG_HELPER_S3_ACCESS_KEY"`
+	SecretKey string `env:"PG_HELPER_S3_SECRET_KEY"`
+	// The file save the secret key
+	SecretKeyFile string `env:"PG_HELPER_S3_SECRET_KEY_FILE"`
+
+	UseSsl bool `name:"use-ssl" default:"true" negatable:"true" help:"Connect to the endpoint with TLS"`
+	// MinIO and most self hosted storages require the path style lookup
+	PathStyle bool `default:"true" negatable:"true" help:"Use path style bucket lookup"`
+}
+
+func (c *StorageConfig) AfterApply() error {
+	if c.Type != StorageTypeS3 {
+		return nil
+	}
+	return c.S3.AfterApply()
+}
+
+func (c *S3StorageConfig) AfterApply() error {
+	if c.Endpoint == "" || c.Bucket == "" {
+		return fmt.Errorf("s3 endpoint and bucket are required")
+	}
+
+	if c.SecretKey == "" && c.SecretKeyFile != "" {
+		secretKey, err := os.ReadFile(c.SecretKeyFile)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to read the s3 secret key file")
+			return err
+		}
+		c.SecretKey = strings.TrimSpace(string(secretKey))
+	}
+	c.Prefix = strings.Trim(c.Prefix, "/")
+	return nil
+}
+
+// ObjectKey returns the object key of the backup path
+func (c *S3StorageConfig) ObjectKey(backupPath string) string {
+	backupPath = strings.TrimPrefix(backupPath, "/")
+	if c.Prefix == "" {
+		return backupPath
+	}
+	return c.Prefix + "/" + backupPath
+}
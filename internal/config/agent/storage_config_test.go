@@ -0,0 +1,29 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestS3StorageConfig_ObjectKey(t *testing.T) {
+	testCases := []struct {
+		name       string
+		prefix     string
+		backupPath string
+		want       string
+	}{
+		{name: "no prefix", backupPath: "pg-16/app/2024-07-10_01:00:00.sql", want: "pg-16/app/2024-07-10_01:00:00.sql"},
+		{name: "prefix", prefix: "backups", backupPath: "pg-16/app/2024-07-10_01:00:00.sql", want: "backups/pg-16/app/2024-07-10_01:00:00.sql"},
+		{name: "absolute path", prefix: "backups", backupPath: "/pg-16/app/2024-07-10_01:00:00.sql", want: "backups/pg-16/app/2024-07-10_01:00:00.sql"},
+		{name: "absolute path without prefix", backupPath: "/pg-16/app", want: "pg-16/app"},
+		{name: "directory", prefix: "backups", backupPath: "pg-16/app/2024-07-10_01:00:00.dir", want: "backups/pg-16/app/2024-07-10_01:00:00.dir"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &S3StorageConfig{Prefix: tc.prefix}
+			assert.Equal(t, tc.want, config.ObjectKey(tc.backupPath))
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package agent
+
+import "time"
+
+// The built-in timeouts by the action of the task,
+// the long running tasks need more time than the default timeout.
+var defaultTaskTimeouts = map[string]time.Duration{
+	"backup":               6 * time.Hour,
+	"daily_backup":         6 * time.Hour,
+	"restore":              6 * time.Hour,
+	"verify_backup":        6 * time.Hour,
+	"base_backup":          12 * time.Hour,
+	"restore_to_timestamp": 12 * time.Hour,
+	// It waits for the logical migration, which is limited by its own replication timeout
+	"wait_replication": 0,
+}
+
+type TaskConfig struct {
+	// The timeout of the tasks that have no timeout of their own, 0 means no timeout.
+	DefaultTimeout time.Duration `default:"1h" help:"The timeout of a task, 0 means no timeout"`
+	// The timeouts by the action of the task, they override the default timeout,
+	// e.g. `restore=12h;backup=6h`, 0 means no timeout.
+	// They are merged over the built-in timeouts, the actions that are not set keep the built-in ones.
+	Timeouts map[string]time.Duration `help:"The timeouts by the action of the task, e.g. restore=12h;backup=6h, they are merged over the built-in ones"`
+}
+
+// Timeout returns the timeout of the task with the action, 0 means no timeout
+func (c *TaskConfig) Timeout(action string) time.Duration {
+	if timeout, ok := c.Timeouts[action]; ok {
+		return timeout
+	}
+	if timeout, ok := defaultTaskTimeouts[action]; ok {
+		return timeout
+	}
+	return c.DefaultTimeout
+}
@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskTimeout(t *testing.T) {
+	config := &TaskConfig{
+		DefaultTimeout: time.Hour,
+		Timeouts: map[string]time.Duration{
+			"restore": 12 * time.Hour,
+			"backup":  0,
+		},
+	}
+
+	assert.Equal(t, 12*time.Hour, config.Timeout("restore"))
+	assert.Equal(t, time.Duration(0), config.Timeout("backup"))
+	assert.Equal(t, time.Hour, config.Timeout("create"))
+
+	// The built-in timeouts are kept if they are not overridden
+	assert.Equal(t, time.Duration(0), config.Timeout("wait_replication"))
+	assert.Equal(t, 12*time.Hour, config.Timeout("base_backup"))
+	assert.Equal(t, 6*time.Hour, config.Timeout("daily_backup"))
+}
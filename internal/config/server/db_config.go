@@ -1,5 +1,48 @@
 package server
 
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
 type DbConfig struct {
 	MandatoryPgVersions []int32 `help:"The PostgreSQL versions that must running"`
+
+	// The url of the database that keeps the server state,
+	// e.g. the database sources, the instance registry and the job history.
+	// The state is kept in memory only if it is empty.
+	Url string `env:"PG_HELPER_SERVER_DB_URL" help:"The url of the database that keeps the server state, the state is lost on restart if it is empty"`
+	// The max connections to the database.
+	MaxConns int32 `default:"4" help:"The max connections to the database that keeps the server state"`
+	// The age identity file to encrypt the passwords of the web database sources,
+	// the passwords are saved in plain text if it is empty.
+	// The first identity encrypts the new passwords,
+	// keep the old identities in the file after rotating the key.
+	PasswordIdentityFile string `env:"PG_HELPER_SERVER_PASSWORD_IDENTITY_FILE" help:"The age identity file to encrypt the passwords of the web database sources, they are saved in plain text if it is empty"`
+}
+
+func (c *DbConfig) NewPoolConfig() (*pgxpool.Config, error) {
+	const defaultMinConns = int32(0)
+	const defaultMaxConnLifetime = time.Hour
+	const defaultMaxConnIdleTime = time.Minute * 30
+	const defaultHealthCheckPeriod = time.Minute
+	const defaultConnectTimeout = time.Second * 5
+
+	dbConfig, err := pgxpool.ParseConfig(c.Url)
+	if err != nil {
+		// Do not log the url, it may contain the password
+		log.Error().Msg("Failed to parse the url of the server database")
+		return nil, err
+	}
+
+	dbConfig.MaxConns = c.MaxConns
+	dbConfig.MinConns = defaultMinConns
+	dbConfig.MaxConnLifetime = defaultMaxConnLifetime
+	dbConfig.MaxConnIdleTime = defaultMaxConnIdleTime
+	dbConfig.HealthCheckPeriod = defaultHealthCheckPeriod
+	dbConfig.ConnConfig.ConnectTimeout = defaultConnectTimeout
+
+	return dbConfig, nil
 }
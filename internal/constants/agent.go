@@ -4,8 +4,10 @@ const (
 	AgentKeyQuitCtx = "quit_ctx"
 	AgentKeyConnCtx = "conn_ctx"
 
-	AgentKeyDbApi                  = "db_api"
-	AgentKeyNotifyDbStatusProducer = "notify_db_status_producer"
+	AgentKeyDbApi                   = "db_api"
+	AgentKeyNotifyDbStatusProducer  = "notify_db_status_producer"
+	AgentKeyNotifyBackupProducer    = "notify_backup_producer"
+	AgentKeyNotifyJobStatusProducer = "notify_job_status_producer"
 
 	AgentKeyGrpcClient = "grpc_client"
 
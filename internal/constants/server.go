@@ -1,9 +1,13 @@
 package constants
 
 const (
-	ServerKeyCronProducer   = "cron_producer"
-	ServerKeySourceProducer = "source_producer"
-	ServerKeySourceHandler  = "source_handler"
-	ServerKeyDbManager      = "db_manager"
-	ServerKeyDbReadyWaiter  = "db_ready_waiter"
+	ServerKeyCronProducer    = "cron_producer"
+	ServerKeySourceProducer  = "source_producer"
+	ServerKeySourceHandler   = "source_handler"
+	ServerKeyDbManager       = "db_manager"
+	ServerKeyDbReadyWaiter   = "db_ready_waiter"
+	ServerKeyBackupCatalog   = "backup_catalog"
+	ServerKeyStore           = "store"
+	ServerKeyInstanceCatalog = "instance_catalog"
+	ServerKeyJobCatalog      = "job_catalog"
 )
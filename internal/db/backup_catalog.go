@@ -0,0 +1,126 @@
+package db
+
+import (
+	config "github.com/a-light-win/pg-helper/internal/config/agent"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// AddBackup adds the backup described by the manifest to the catalog
+func (api *DbApi) AddBackup(manifest *config.BackupManifest, q *Queries) error {
+	if q == nil {
+		return api.Query(func(q *Queries) error {
+			return api.AddBackup(manifest, q)
+		})
+	}
+
+	backup, err := q.UpsertBackup(api.ConnCtx, UpsertBackupParams{
+		DbName:         manifest.DbName,
+		Path:           manifest.BackupPath,
+		Format:         manifest.Format,
+		Compression:    manifest.Compression,
+		Encrypted:      manifest.Encryption != "",
+		Size:           manifest.Size,
+		Sha256:         manifest.Sha256,
+		SourceInstance: manifest.SourceInstance,
+		PgMajor:        manifest.PgMajor,
+		CreatedAt:      pgtype.Timestamp{Time: manifest.CreatedAt.UTC(), Valid: true},
+	})
+	if err != nil {
+		log.Warn().Err(err).
+			Str("BackupPath", manifest.BackupPath).
+			Msg("Can not add the backup to catalog")
+		return err
+	}
+
+	api.NotifyBackupChanged(backup.ToProto())
+	return nil
+}
+
+// RemoveBackup removes the backup from the catalog, it is ok if the backup is not in the catalog.
+func (api *DbApi) RemoveBackup(path string, q *Queries) error {
+	if q == nil {
+		return api.Query(func(q *Queries) error {
+			return api.RemoveBackup(path, q)
+		})
+	}
+
+	backup, err := q.DeleteBackupByPath(api.ConnCtx, path)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		log.Warn().Err(err).
+			Str("BackupPath", path).
+			Msg("Can not remove the backup from catalog")
+		return err
+	}
+
+	backup_ := backup.ToProto()
+	backup_.Deleted = true
+	api.NotifyBackupChanged(backup_)
+	return nil
+}
+
+func (api *DbApi) SetBackupVerified(path string, passed bool, q *Queries) error {
+	if q == nil {
+		return api.Query(func(q *Queries) error {
+			return api.SetBackupVerified(path, passed, q)
+		})
+	}
+
+	backup, err := q.SetBackupVerified(api.ConnCtx, SetBackupVerifiedParams{
+		Path:         path,
+		VerifyPassed: passed,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		log.Warn().Err(err).
+			Str("BackupPath", path).
+			Msg("Can not set the backup verified")
+		return err
+	}
+
+	api.NotifyBackupChanged(backup.ToProto())
+	return nil
+}
+
+func (api *DbApi) ListBackups(q *Queries) ([]Backup, error) {
+	if q == nil {
+		var backups []Backup
+		var err error
+		api.Query(func(q *Queries) error {
+			backups, err = api.ListBackups(q)
+			return err
+		})
+		return backups, err
+	}
+
+	return q.ListBackups(api.ConnCtx)
+}
+
+func (api *DbApi) ToProtoBackups(backups []Backup) []*proto.Backup {
+	protoBackups := make([]*proto.Backup, 0, len(backups))
+	for i := range backups {
+		backup := backups[i].ToProto()
+		backup.InstanceName = api.DbConfig.InstanceName
+		protoBackups = append(protoBackups, backup)
+	}
+	return protoBackups
+}
+
+func (api *DbApi) NotifyBackupChanged(backup *proto.Backup) {
+	log.Info().Str("DbName", backup.Name).
+		Str("BackupPath", backup.Path).
+		Bool("Deleted", backup.Deleted).
+		Msg("Backup catalog changed")
+
+	backup.InstanceName = api.DbConfig.InstanceName
+	if api.BackupNotifier != nil {
+		api.BackupNotifier.Send(backup)
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/a-light-win/pg-helper/pkg/proto"
 	"github.com/a-light-win/pg-helper/pkg/server"
 	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/rs/zerolog"
@@ -22,7 +24,9 @@ type DbApi struct {
 	ConnCtx context.Context
 	Cancel  context.CancelFunc
 
-	DbStatusNotifier server.Producer
+	DbStatusNotifier  server.Producer
+	BackupNotifier    server.Producer
+	JobStatusNotifier server.Producer
 }
 
 func (q *Queries) Conn() *pgx.Conn {
@@ -107,6 +111,22 @@ func (api *DbApi) UpdateTaskStatus(task *DbTask, q *Queries) error {
 		})
 	}
 
+	if err := api.SetTaskStatus(task, q); err != nil {
+		return err
+	}
+	api.NotifyJobStatusChanged(task.JobID, q)
+	return nil
+}
+
+// SetTaskStatus updates the status of the task without reporting it to the server,
+// the callers in a transaction should call NotifyJobStatusChanged after the commit.
+func (api *DbApi) SetTaskStatus(task *DbTask, q *Queries) error {
+	if q == nil {
+		return api.Query(func(q *Queries) error {
+			return api.SetTaskStatus(task, q)
+		})
+	}
+
 	dbTaskParams := SetDbTaskStatusParams{
 		ID:        task.ID,
 		Status:    task.Status,
@@ -125,7 +145,58 @@ func (api *DbApi) UpdateTaskStatus(task *DbTask, q *Queries) error {
 	}
 
 	task.UpdatedAt = newTask.UpdatedAt
+	return nil
+}
+
+// NotifyJobStatusChanged reports all the tasks of the job to the server
+func (api *DbApi) NotifyJobStatusChanged(jobID uuid.UUID, q *Queries) {
+	if api.JobStatusNotifier == nil {
+		return
+	}
+	if q == nil {
+		api.Query(func(q *Queries) error {
+			api.NotifyJobStatusChanged(jobID, q)
+			return nil
+		})
+		return
+	}
+
+	tasks, err := q.ListDbTasksByJobID(api.ConnCtx, jobID)
+	if err != nil {
+		log.Warn().Err(err).
+			Interface("JobID", jobID).
+			Msg("can not load the tasks of the job")
+		return
+	}
 
+	jobStatus := &proto.JobStatus{
+		JobId:        jobID.String(),
+		InstanceName: api.DbConfig.InstanceName,
+		Tasks:        make([]*proto.TaskStatus, 0, len(tasks)),
+	}
+	for i := range tasks {
+		jobStatus.Tasks = append(jobStatus.Tasks, tasks[i].ToProto())
+	}
+	api.JobStatusNotifier.Send(jobStatus)
+}
+
+func (api *DbApi) UpdateTaskData(task *DbTask, q *Queries) error {
+	if q == nil {
+		return api.Query(func(q *Queries) error {
+			return api.UpdateTaskData(task, q)
+		})
+	}
+
+	params := SetDbTaskDataParams{
+		ID:   task.ID,
+		Data: task.Data,
+	}
+	if err := q.SetDbTaskData(api.ConnCtx, params); err != nil {
+		log.Warn().Err(err).
+			Interface("TaskID", task.ID).
+			Msg("can not update task data")
+		return err
+	}
 	return nil
 }
 
@@ -244,6 +315,25 @@ func (api *DbApi) CreateDb(params *CreateDbParams, q *Queries) (*Db, error) {
 	return &db, nil
 }
 
+// ReuseDroppedDb resets the record of the dropped database,
+// so a new database can be created with the same name.
+func (api *DbApi) ReuseDroppedDb(db *Db, owner string, q *Queries) (*Db, error) {
+	params := ResetDbParams{
+		ID:     db.ID,
+		Owner:  owner,
+		Stage:  proto.DbStage_None,
+		Status: proto.DbStatus_Processing,
+	}
+	newDb, err := q.ResetDb(api.ConnCtx, params)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("DbName", db.Name).
+			Msg("Can not reset the record of the dropped database")
+		return nil, err
+	}
+	return &newDb, nil
+}
+
 func (api *DbApi) CreateDbTask(params *CreateDbTaskParams, q *Queries) (*DbTask, error) {
 	if q == nil {
 		var task *DbTask
@@ -297,6 +387,72 @@ func (api *DbApi) ListDbs(q *Queries) ([]Db, error) {
 	return dbs, nil
 }
 
+func (api *DbApi) ListExpiredIdleDbs(q *Queries) ([]Db, error) {
+	return api.listExpiredDbs(proto.DbStage_Idle, proto.DbStatus_Done, q)
+}
+
+// List the expired idle databases that are failed to drop
+func (api *DbApi) ListExpiredDropFailedDbs(q *Queries) ([]Db, error) {
+	return api.listExpiredDbs(proto.DbStage_DropDatabase, proto.DbStatus_Failed, q)
+}
+
+// List the ready to use databases that are expired, e.g. the cloned databases with a TTL
+func (api *DbApi) ListExpiredReadyDbs(q *Queries) ([]Db, error) {
+	return api.listExpiredDbs(proto.DbStage_ReadyToUse, proto.DbStatus_Done, q)
+}
+
+func (api *DbApi) listExpiredDbs(stage proto.DbStage, status proto.DbStatus, q *Queries) ([]Db, error) {
+	if q == nil {
+		var dbs []Db
+		var err error
+		api.Query(func(q *Queries) error {
+			dbs, err = api.listExpiredDbs(stage, status, q)
+			return err
+		})
+		return dbs, err
+	}
+
+	params := ListExpiredDbsParams{
+		Stage:  stage,
+		Status: status,
+	}
+	dbs, err := q.ListExpiredDbs(api.ConnCtx, params)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []Db{}, nil
+		}
+		return nil, err
+	}
+	return dbs, nil
+}
+
+// List the ready to use databases that are not backed up since the given time
+func (api *DbApi) ListDbsToDailyBackup(since time.Time, q *Queries) ([]Db, error) {
+	if q == nil {
+		var dbs []Db
+		var err error
+		api.Query(func(q *Queries) error {
+			dbs, err = api.ListDbsToDailyBackup(since, q)
+			return err
+		})
+		return dbs, err
+	}
+
+	params := ListDbsToDailyBackupParams{
+		Stage:  proto.DbStage_ReadyToUse,
+		Status: proto.DbStatus_Done,
+		Since:  pgtype.Timestamp{Time: since.UTC(), Valid: true},
+	}
+	dbs, err := q.ListDbsToDailyBackup(api.ConnCtx, params)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []Db{}, nil
+		}
+		return nil, err
+	}
+	return dbs, nil
+}
+
 func (api *DbApi) ToProtoDatabases(dbs []Db) []*proto.Database {
 	if len(dbs) == 0 {
 		return []*proto.Database{}
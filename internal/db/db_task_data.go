@@ -1,6 +1,10 @@
 package db
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type DbTaskData struct {
 	// This task depends on other tasks
@@ -14,7 +18,7 @@ type DbTaskData struct {
 
 	// The pg instance to run backup task
 	BackupFrom string `json:"backup_from"`
-	// The backup path of the database, in format of `pg-<major>/<database name>/<timestamp>.sql`
+	// The backup path of the database, in format of `pg-<major>/<database name>/<timestamp>.<ext>`
 	// e.g. `pg-12/mydb/2021-01-01T01:01:01.sql`
 	//
 	// This path is relative to DbConfig.BackupRootPath
@@ -23,9 +27,78 @@ type DbTaskData struct {
 	// - backup
 	// - remote-backup
 	// - restore
+	//
+	// In base_backup tasks, it is the base backup directory
+	// relative to PitrConfig.BaseBackupRootPath
 	BackupPath string `json:"backup_path"`
+	// The format of the backup, one of plain, custom, directory,
+	// the format is detected by the extension of BackupPath if empty.
+	//
+	// Valid in following tasks:
+	// - backup
+	// - restore
+	BackupFormat string `json:"backup_format,omitempty"`
+
+	// The backups pruned by the retention policy,
+	// relative to DbConfig.BackupRootPath
+	//
+	// Valid in following tasks:
+	// - prune_backups
+	PrunedBackups []string `json:"pruned_backups,omitempty"`
+
+	// The SQL assertions to run in the scratch database,
+	// each of them returns a single boolean.
+	//
+	// Valid in following tasks:
+	// - verify_backup
+	VerifyAssertions []string `json:"verify_assertions,omitempty"`
+	// The result of the backup verification
+	//
+	// Valid in following tasks:
+	// - verify_backup
+	TableCount       int64    `json:"table_count,omitempty"`
+	FailedAssertions []string `json:"failed_assertions,omitempty"`
+
+	// The target time of the point-in-time recovery
+	//
+	// Valid in following tasks:
+	// - restore_to_timestamp
+	TargetTime *time.Time `json:"target_time,omitempty"`
+	// The new data directory that the recovery is prepared in
+	//
+	// Valid in following tasks:
+	// - restore_to_timestamp
+	DataDir string `json:"data_dir,omitempty"`
+
+	// The database that the backup is taken from, it is the database of the task if empty.
+	// It differs from the database of the task when cloning a database.
+	//
+	// Valid in following tasks:
+	// - backup
+	// - restore
+	SourceDb string `json:"source_db,omitempty"`
+
+	// Drop and recreate the existing database before restoring,
+	// it is used to restore an in-use database from a chosen backup.
+	//
+	// Valid in following tasks:
+	// - restore
+	InPlace bool `json:"in_place,omitempty"`
+
+	// The pg instance that the database is replicated from
+	//
+	// Valid in following tasks:
+	// - replicate
+	// - wait_replication
+	// - cutover
+	ReplicateFrom string `json:"replicate_from,omitempty"`
 
 	Owner string `json:"owner"`
+	// Drop the owner role after the database is dropped
+	//
+	// Valid in following tasks:
+	// - drop
+	DropOwner bool `json:"drop_owner"`
 
 	// Do not save password to database
 	Password string `json:"-"`
@@ -26,9 +26,42 @@ func (db *Db) ToProto() *proto.Database {
 	}
 }
 
+func (b *Backup) ToProto() *proto.Backup {
+	if b == nil {
+		return nil
+	}
+	return &proto.Backup{
+		Name:           b.DbName,
+		Path:           b.Path,
+		Format:         b.Format,
+		Compression:    b.Compression,
+		Encrypted:      b.Encrypted,
+		Size:           b.Size,
+		Sha256:         b.Sha256,
+		SourceInstance: b.SourceInstance,
+		PgMajor:        b.PgMajor,
+		CreatedAt:      pgTimestampToProto(b.CreatedAt),
+		VerifiedAt:     pgTimestampToProto(b.VerifiedAt),
+		VerifyPassed:   b.VerifyPassed,
+	}
+}
+
 func pgTimestampToProto(ts pgtype.Timestamp) *timestamppb.Timestamp {
 	if !ts.Valid {
 		return nil
 	}
 	return timestamppb.New(ts.Time)
 }
+
+func (t *DbTask) ToProto() *proto.TaskStatus {
+	return &proto.TaskStatus{
+		TaskId:    t.ID.String(),
+		DbName:    t.DbName,
+		Action:    string(t.Action),
+		Reason:    t.Reason,
+		Status:    string(t.Status),
+		ErrReason: t.Data.ErrReason,
+		CreatedAt: pgTimestampToProto(t.CreatedAt),
+		UpdatedAt: pgTimestampToProto(t.UpdatedAt),
+	}
+}
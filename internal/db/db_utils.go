@@ -11,17 +11,63 @@ func (d *Db) IsNotExist() bool {
 	return d.Stage == proto.DbStage_None
 }
 
+func (d *Db) IsDropped() bool {
+	return d.Stage == proto.DbStage_DropDatabase && d.Status == proto.DbStatus_Done
+}
+
 func (d *Db) IsReadyToUse() bool {
 	return d.Stage == proto.DbStage_ReadyToUse && d.Status == proto.DbStatus_Done
 }
 
+// The empty database is created and can be restored from a backup,
+// or the previous restore is failed and can be retried when the job is resumed.
 func (d *Db) CanRestore() bool {
-	return d.Status == proto.DbStatus_Done &&
+	return (d.Status == proto.DbStatus_Done &&
 		(d.Stage == proto.DbStage_CreateDatabase ||
-			d.Stage == proto.DbStage_RestoreDatabase)
+			d.Stage == proto.DbStage_RestoreDatabase)) ||
+		(d.Stage == proto.DbStage_RestoreDatabase && d.Status == proto.DbStatus_Failed)
+}
+
+// The database is in use and can be restored in place,
+// or the previous in-place restore is failed and can be retried.
+func (d *Db) CanRestoreInPlace() bool {
+	return d.IsReadyToUse() ||
+		(d.Stage == proto.DbStage_RestoreDatabase && d.Status == proto.DbStatus_Failed)
+}
+
+// The empty database is created and can be replicated from the old pg instance,
+// or the previous replication is failed and can be retried.
+func (d *Db) CanReplicate() bool {
+	return (d.Stage == proto.DbStage_CreateDatabase && d.Status == proto.DbStatus_Done) ||
+		(d.Stage == proto.DbStage_ReplicateDatabase && d.Status == proto.DbStatus_Failed)
+}
+
+func (d *Db) IsReplicating() bool {
+	return d.Stage == proto.DbStage_ReplicateDatabase && d.Status == proto.DbStatus_Processing
 }
 
 func (d *Db) ShouldBackup() bool {
 	return (d.Stage == proto.DbStage_CreateDatabase && d.Status == proto.DbStatus_Done) ||
 		(d.Stage == proto.DbStage_BackupDatabase && d.Status == proto.DbStatus_Failed)
 }
+
+// The database is stable and can be backed up without changing its stage
+func (d *Db) CanBackup() bool {
+	return d.IsReadyToUse() || (d.IsAlreadyIdle() && !d.IsDropped())
+}
+
+func (d *Db) CanDrop() bool {
+	return (d.Stage == proto.DbStage_Idle && d.Status == proto.DbStatus_Done) ||
+		(d.Stage == proto.DbStage_DropDatabase && d.Status == proto.DbStatus_Failed)
+}
+
+// The database is in use, or the migrate out request is accepted
+// and the task to idle it is not done yet.
+func (d *Db) CanMigrateOut() bool {
+	return d.IsReadyToUse() ||
+		(d.Stage == proto.DbStage_Idle && d.Status != proto.DbStatus_Done)
+}
+
+func (d *Db) CanRollback() bool {
+	return d.Stage == proto.DbStage_Idle && d.Status != proto.DbStatus_Processing
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDb_CanRestore(t *testing.T) {
+	tests := []struct {
+		name   string
+		stage  proto.DbStage
+		status proto.DbStatus
+		want   bool
+	}{
+		{"Created", proto.DbStage_CreateDatabase, proto.DbStatus_Done, true},
+		{"Creating", proto.DbStage_CreateDatabase, proto.DbStatus_Processing, false},
+		{"CreateFailed", proto.DbStage_CreateDatabase, proto.DbStatus_Failed, false},
+		{"Restored", proto.DbStage_RestoreDatabase, proto.DbStatus_Done, true},
+		{"Restoring", proto.DbStage_RestoreDatabase, proto.DbStatus_Processing, false},
+		// The job is resumed after the restore failed
+		{"RestoreFailed", proto.DbStage_RestoreDatabase, proto.DbStatus_Failed, true},
+		{"ReadyToUse", proto.DbStage_ReadyToUse, proto.DbStatus_Done, false},
+		{"Idle", proto.DbStage_Idle, proto.DbStatus_Done, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Db{Stage: tt.stage, Status: tt.status}
+			assert.Equal(t, tt.want, d.CanRestore())
+		})
+	}
+}
+
+func TestDb_CanRestoreInPlace(t *testing.T) {
+	tests := []struct {
+		name   string
+		stage  proto.DbStage
+		status proto.DbStatus
+		want   bool
+	}{
+		{"ReadyToUse", proto.DbStage_ReadyToUse, proto.DbStatus_Done, true},
+		{"RestoreFailed", proto.DbStage_RestoreDatabase, proto.DbStatus_Failed, true},
+		{"Restoring", proto.DbStage_RestoreDatabase, proto.DbStatus_Processing, false},
+		{"Created", proto.DbStage_CreateDatabase, proto.DbStatus_Done, false},
+		{"Idle", proto.DbStage_Idle, proto.DbStatus_Done, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Db{Stage: tt.stage, Status: tt.status}
+			assert.Equal(t, tt.want, d.CanRestoreInPlace())
+		})
+	}
+}
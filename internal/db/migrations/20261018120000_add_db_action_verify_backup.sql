@@ -0,0 +1,9 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- +goose StatementBegin
+ALTER TYPE DB_ACTION ADD VALUE IF NOT EXISTS 'verify_backup';
+-- +goose StatementEnd
+
+-- +goose Down
+-- Postgres does not support removing a value from an enum type,
+-- the 'verify_backup' value is kept.
@@ -0,0 +1,33 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- +goose StatementBegin
+ALTER TYPE DB_ACTION ADD VALUE IF NOT EXISTS 'base_backup';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TYPE DB_ACTION ADD VALUE IF NOT EXISTS 'restore_to_timestamp';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS base_backups (
+  id BIGSERIAL PRIMARY KEY,
+  -- relative to PitrConfig.BaseBackupRootPath
+  path TEXT NOT NULL,
+  start_wal_location TEXT NOT NULL,
+  start_wal_file TEXT NOT NULL,
+  size BIGINT NOT NULL DEFAULT 0,
+  started_at TIMESTAMP NOT NULL,
+  finished_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now())
+);
+
+CREATE UNIQUE INDEX base_backups_path_idx ON base_backups (path);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS base_backups_path_idx;
+DROP TABLE IF EXISTS base_backups;
+-- +goose StatementEnd
+-- Postgres does not support removing a value from an enum type,
+-- the 'base_backup' and 'restore_to_timestamp' values are kept.
@@ -0,0 +1,30 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS backups (
+  id BIGSERIAL PRIMARY KEY,
+  db_name TEXT NOT NULL,
+  -- relative to DbConfig.BackupRootPath
+  path TEXT NOT NULL,
+  format TEXT NOT NULL,
+  compression TEXT NOT NULL DEFAULT 'none',
+  encrypted BOOLEAN NOT NULL DEFAULT false,
+  size BIGINT NOT NULL DEFAULT 0,
+  sha256 TEXT NOT NULL DEFAULT '',
+  source_instance TEXT NOT NULL DEFAULT '',
+  pg_major int4 NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now()),
+  verified_at TIMESTAMP,
+  verify_passed BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE UNIQUE INDEX backups_path_idx ON backups (path);
+CREATE INDEX backups_db_name_idx ON backups (db_name);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS backups_db_name_idx;
+DROP INDEX IF EXISTS backups_path_idx;
+DROP TABLE IF EXISTS backups;
+-- +goose StatementEnd
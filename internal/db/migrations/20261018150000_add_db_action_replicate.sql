@@ -0,0 +1,17 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- +goose StatementBegin
+ALTER TYPE DB_ACTION ADD VALUE IF NOT EXISTS 'replicate';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TYPE DB_ACTION ADD VALUE IF NOT EXISTS 'wait_replication';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TYPE DB_ACTION ADD VALUE IF NOT EXISTS 'cutover';
+-- +goose StatementEnd
+
+-- +goose Down
+-- Postgres does not support removing a value from an enum type,
+-- the 'replicate', 'wait_replication' and 'cutover' values are kept.
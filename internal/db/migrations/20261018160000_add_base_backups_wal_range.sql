@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE base_backups
+  ADD COLUMN IF NOT EXISTS timeline INT NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS stop_wal_location TEXT NOT NULL DEFAULT '',
+  -- The last WAL segment that the base backup needs to be consistent,
+  -- it is empty for the base backups taken before the WAL range is recorded.
+  ADD COLUMN IF NOT EXISTS stop_wal_file TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE base_backups
+  DROP COLUMN IF EXISTS timeline,
+  DROP COLUMN IF EXISTS stop_wal_location,
+  DROP COLUMN IF EXISTS stop_wal_file;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- name: UpsertBackup :one
+INSERT INTO backups (db_name, path, format, compression, encrypted, size, sha256, source_instance, pg_major, created_at)
+VALUES (@db_name, @path, @format, @compression, @encrypted, @size, @sha256, @source_instance, @pg_major, @created_at)
+ON CONFLICT (path) DO UPDATE SET
+  format = EXCLUDED.format,
+  compression = EXCLUDED.compression,
+  encrypted = EXCLUDED.encrypted,
+  size = EXCLUDED.size,
+  sha256 = EXCLUDED.sha256,
+  source_instance = EXCLUDED.source_instance,
+  pg_major = EXCLUDED.pg_major,
+  created_at = EXCLUDED.created_at
+RETURNING *;
+
+-- name: DeleteBackupByPath :one
+DELETE FROM backups WHERE path = @path RETURNING *;
+
+-- name: GetBackupByPath :one
+SELECT * FROM backups WHERE path = @path;
+
+-- name: ListBackups :many
+SELECT * FROM backups ORDER BY created_at DESC;
+
+-- name: SetBackupVerified :one
+UPDATE backups SET verified_at = timezone('utc', now()), verify_passed = @verify_passed
+WHERE path = @path
+RETURNING *;
@@ -0,0 +1,20 @@
+-- name: CreateBaseBackup :one
+INSERT INTO base_backups (path, timeline, start_wal_location, start_wal_file, stop_wal_location, stop_wal_file, size, started_at, finished_at)
+VALUES (@path, @timeline, @start_wal_location, @start_wal_file, @stop_wal_location, @stop_wal_file, @size, @started_at, @finished_at)
+RETURNING *;
+
+-- name: ListBaseBackups :many
+SELECT * FROM base_backups
+ORDER BY finished_at DESC;
+
+-- name: GetLatestBaseBackupBefore :one
+SELECT * FROM base_backups
+WHERE finished_at <= @target_time
+ORDER BY finished_at DESC
+LIMIT 1;
+
+-- name: DeleteBaseBackup :exec
+DELETE FROM base_backups WHERE id = @id;
+
+-- name: GetWalFileName :one
+SELECT pg_walfile_name(sqlc.arg(lsn)::text::pg_lsn)::text AS wal_file;
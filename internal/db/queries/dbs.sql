@@ -13,6 +13,14 @@ UPDATE dbs SET status = @status, stage = @stage,
 WHERE id = @id AND updated_at = @updated_at
 RETURNING *;
 
+-- name: ResetDb :one
+UPDATE dbs SET owner = @owner, stage = @stage, status = @status,
+	error_msg = '', updated_at = timezone('utc', now()),
+	expired_at = NULL, last_job_id = NULL,
+	migrate_from = '', migrate_to = ''
+WHERE id = @id
+RETURNING *;
+
 -- name: GetDbByName :one
 SELECT * FROM dbs WHERE name = @name;
 
@@ -22,3 +30,27 @@ SELECT * FROM dbs WHERE id = @id;
 -- name: ListDbs :many
 SELECT * FROM dbs
 ORDER BY status, name;
+
+-- name: ListExpiredDbs :many
+SELECT * FROM dbs
+WHERE dbs.stage = @stage AND dbs.status = @status
+AND dbs.expired_at IS NOT NULL
+AND dbs.expired_at < timezone('utc', now())
+AND NOT EXISTS (
+	SELECT 1 FROM db_tasks
+	WHERE db_tasks.db_id = dbs.id
+	AND db_tasks.status in ('pending', 'running')
+)
+ORDER BY dbs.expired_at;
+
+-- name: ListDbsToDailyBackup :many
+SELECT * FROM dbs
+WHERE dbs.stage = @stage AND dbs.status = @status
+AND NOT EXISTS (
+	SELECT 1 FROM db_tasks
+	WHERE db_tasks.db_id = dbs.id
+	AND (db_tasks.status in ('pending', 'running')
+		OR (db_tasks.action = 'daily_backup' AND db_tasks.created_at >= @since
+			AND db_tasks.status in ('completed', 'pending', 'running')))
+)
+ORDER BY dbs.name;
@@ -10,3 +10,12 @@ WHERE dbs.datname = @dbName;
 -- name: CountDbTables :one
 SELECT COUNT(*) FROM pg_catalog.pg_tables
 WHERE schemaname not in ('pg_catalog', 'information_schema', 'pg_toast');
+
+-- name: TerminateDbConnections :execrows
+SELECT pg_terminate_backend(pid) FROM pg_catalog.pg_stat_activity
+WHERE datname = @dbName AND pid <> pg_backend_pid();
+
+-- name: CountDbsOwnedBy :one
+SELECT COUNT(*) FROM pg_catalog.pg_database dbs
+JOIN pg_catalog.pg_roles r ON r.oid = dbs.datdba
+WHERE r.rolname = @owner;
@@ -0,0 +1,63 @@
+package db_task
+
+import (
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// syncBackupCatalog makes the backup catalog consistent with the storage,
+// the backups created by the old versions or removed out of band are synced here.
+func (h *DbTaskHandler) syncBackupCatalog() error {
+	dbs, err := h.DbApi.ListDbs(nil)
+	if err != nil {
+		return err
+	}
+
+	cataloged, err := h.DbApi.ListBackups(nil)
+	if err != nil {
+		return err
+	}
+	stale := make(map[string]*db.Backup, len(cataloged))
+	for i := range cataloged {
+		stale[cataloged[i].Path] = &cataloged[i]
+	}
+
+	for _, db_ := range dbs {
+		backups, err := h.listBackups(db_.Name)
+		if err != nil {
+			log.Warn().Err(err).
+				Str("DbName", db_.Name).
+				Msg("Failed to list backups when syncing the backup catalog")
+			// Keep the cataloged backups of this db, we do not know if they exist.
+			for path, backup := range stale {
+				if backup.DbName == db_.Name {
+					delete(stale, path)
+				}
+			}
+			continue
+		}
+
+		for _, backup := range backups {
+			if _, ok := stale[backup.Path]; ok {
+				delete(stale, backup.Path)
+				continue
+			}
+
+			manifest, err := storage.LoadManifest(h.DbApi.ConnCtx, h.Storage, backup.Path)
+			if err != nil {
+				log.Debug().Err(err).
+					Str("DbName", db_.Name).
+					Str("BackupPath", backup.Path).
+					Msg("Skip the backup without manifest")
+				continue
+			}
+			h.DbApi.AddBackup(manifest, nil)
+		}
+	}
+
+	for path := range stale {
+		h.DbApi.RemoveBackup(path, nil)
+	}
+	return nil
+}
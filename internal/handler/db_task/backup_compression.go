@@ -0,0 +1,85 @@
+package db_task
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	config "github.com/a-light-win/pg-helper/internal/config/agent"
+	"github.com/klauspost/compress/zstd"
+)
+
+func compressFile(src string, dst string, compression string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	var w io.WriteCloser
+	switch compression {
+	case config.BackupCompressionGzip:
+		w = gzip.NewWriter(out)
+	case config.BackupCompressionZstd:
+		if w, err = zstd.NewWriter(out); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid backup compression %s", compression)
+	}
+
+	if _, err := io.Copy(w, in); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func decompressFile(src string, dst string, compression string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var r io.Reader
+	switch compression {
+	case config.BackupCompressionGzip:
+		gr, err := gzip.NewReader(in)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	case config.BackupCompressionZstd:
+		zr, err := zstd.NewReader(in)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return fmt.Errorf("invalid backup compression %s", compression)
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, r); err != nil {
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,81 @@
+package db_task
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+)
+
+// newWorkDir creates the dir to stage the backup before encrypting or after decrypting.
+// The dir is private to the agent if the backup is encrypted,
+// so the unencrypted backup is never written to the shared BackupRootPath.
+func (h *DbTaskHandler) newWorkDir(pattern string, encrypted bool) (string, error) {
+	if encrypted {
+		return os.MkdirTemp(h.BackupConfig.Encryption.TmpDir, pattern)
+	}
+	return os.MkdirTemp(h.DbConfig.BackupRootPath, pattern)
+}
+
+// encryptPath encrypts the backup file or each file of the backup directory from src to dst
+func encryptPath(src string, dst string, recipient age.Recipient) error {
+	return transformPath(src, dst, func(in io.Reader, out io.Writer) error {
+		w, err := age.Encrypt(out, recipient)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, in); err != nil {
+			w.Close()
+			return err
+		}
+		return w.Close()
+	})
+}
+
+// decryptPath decrypts the backup file or each file of the backup directory from src to dst
+func decryptPath(src string, dst string, identity age.Identity) error {
+	return transformPath(src, dst, func(in io.Reader, out io.Writer) error {
+		r, err := age.Decrypt(in, identity)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, r)
+		return err
+	})
+}
+
+func transformPath(src string, dst string, transform func(in io.Reader, out io.Writer) error) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0750)
+		}
+
+		in, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		if err := transform(in, out); err != nil {
+			return err
+		}
+		return out.Close()
+	})
+}
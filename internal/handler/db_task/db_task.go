@@ -1,6 +1,11 @@
 package db_task
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
 	"github.com/a-light-win/pg-helper/internal/db"
 	"github.com/a-light-win/pg-helper/internal/job"
 	"github.com/google/uuid"
@@ -11,6 +16,14 @@ type DbTask struct {
 	dbApi *db.DbApi
 
 	*job.BaseTaskDependency
+
+	// The context of the running task,
+	// it is cancelled when the task is interrupted or timed out.
+	ctx             context.Context
+	timeout         time.Duration
+	cancelRun       context.CancelFunc
+	interruptReason string
+	interruptLock   sync.Mutex
 }
 
 func NewDbTask(task *db.DbTask, dbApi *db.DbApi) job.Task {
@@ -31,6 +44,19 @@ func (t *DbTask) GetName() string {
 	return t.ID.String()
 }
 
+// SourceDbName returns the database that the backup of the task is taken from
+func (t *DbTask) SourceDbName() string {
+	if t.Data.SourceDb != "" {
+		return t.Data.SourceDb
+	}
+	return t.DbName
+}
+
+// IsClone reports whether the backup of the task is taken from another database
+func (t *DbTask) IsClone() bool {
+	return t.Data.SourceDb != "" && t.Data.SourceDb != t.DbName
+}
+
 func (t *DbTask) Requires() []uuid.UUID {
 	return t.Data.DependsOn
 }
@@ -62,7 +88,63 @@ func (t *DbTask) IsFailed() bool {
 }
 
 func (t *DbTask) Cancel(reason string) {
+	// The task may be picked up by a worker already
+	t.Interrupt(reason)
+
 	t.Status = db.DbTaskStatusCancelled
 	t.Data.ErrReason = reason
 	t.dbApi.UpdateTaskStatus(t.DbTask, nil)
 }
+
+func (t *DbTask) Interrupt(reason string) {
+	t.interruptLock.Lock()
+	defer t.interruptLock.Unlock()
+
+	t.interruptReason = reason
+	if t.cancelRun != nil {
+		t.cancelRun()
+	}
+}
+
+// interrupted returns the reason if the task is interrupted
+func (t *DbTask) interrupted() (string, bool) {
+	t.interruptLock.Lock()
+	defer t.interruptLock.Unlock()
+
+	return t.interruptReason, t.interruptReason != ""
+}
+
+// Context returns the context of the running task,
+// the child processes of the task are killed when it is cancelled.
+func (t *DbTask) Context() context.Context {
+	return t.ctx
+}
+
+// timedOut returns the timeout if the task is timed out
+func (t *DbTask) timedOut() (time.Duration, bool) {
+	return t.timeout, t.ctx != nil && errors.Is(t.ctx.Err(), context.DeadlineExceeded)
+}
+
+// start prepares the context to run the task, 0 timeout means no timeout
+func (t *DbTask) start(parent context.Context, timeout time.Duration) {
+	t.interruptLock.Lock()
+	defer t.interruptLock.Unlock()
+
+	t.timeout = timeout
+	if timeout > 0 {
+		t.ctx, t.cancelRun = context.WithTimeout(parent, timeout)
+	} else {
+		t.ctx, t.cancelRun = context.WithCancel(parent)
+	}
+	if t.interruptReason != "" {
+		t.cancelRun()
+	}
+}
+
+func (t *DbTask) stop() {
+	t.interruptLock.Lock()
+	defer t.interruptLock.Unlock()
+
+	t.cancelRun()
+	t.cancelRun = nil
+}
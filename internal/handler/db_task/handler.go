@@ -9,6 +9,7 @@ import (
 	"github.com/a-light-win/pg-helper/internal/constants"
 	"github.com/a-light-win/pg-helper/internal/db"
 	"github.com/a-light-win/pg-helper/internal/job"
+	"github.com/a-light-win/pg-helper/internal/storage"
 	"github.com/a-light-win/pg-helper/pkg/proto"
 	"github.com/a-light-win/pg-helper/pkg/server"
 	"github.com/google/uuid"
@@ -17,15 +18,21 @@ import (
 )
 
 type DbTaskHandler struct {
-	DbApi    *db.DbApi
-	DbConfig *config.DbConfig
-
-	jobProducer server.Producer
+	DbApi        *db.DbApi
+	DbConfig     *config.DbConfig
+	BackupConfig *config.BackupConfig
+	TaskConfig   *config.TaskConfig
+	Storage      storage.Storage
+
+	jobProducer    server.Producer
+	backupNotifier server.Producer
 }
 
-func NewDbTaskHandler(dbConfig *config.DbConfig) *DbTaskHandler {
+func NewDbTaskHandler(dbConfig *config.DbConfig, backupConfig *config.BackupConfig, taskConfig *config.TaskConfig) *DbTaskHandler {
 	return &DbTaskHandler{
-		DbConfig: dbConfig,
+		DbConfig:     dbConfig,
+		BackupConfig: backupConfig,
+		TaskConfig:   taskConfig,
 	}
 }
 
@@ -35,6 +42,11 @@ func (h *DbTaskHandler) Handle(msg server.NamedElement) error {
 		return errors.New("invalid job type")
 	}
 
+	if task.IsDone() {
+		// The task is cancelled with its job before it runs
+		return nil
+	}
+
 	err := h.handle(task)
 	h.jobProducer.Send(task)
 	return err
@@ -45,6 +57,13 @@ func (h *DbTaskHandler) handle(dbTask *DbTask) error {
 		dbTask.Status = db.DbTaskStatusCancelled
 		return h.DbApi.UpdateTaskStatus(dbTask.DbTask, nil)
 	}
+	if dbTask.IsCancelling() {
+		dbTask.Cancel(fmt.Sprintf("Task is cancelled by %s", dbTask.CancelledBy().String()))
+		return nil
+	}
+
+	dbTask.start(h.DbApi.ConnCtx, h.TaskConfig.Timeout(string(dbTask.Action)))
+	defer dbTask.stop()
 
 	switch dbTask.Action {
 	case db.DbActionMigrateOut:
@@ -53,12 +72,30 @@ func (h *DbTaskHandler) handle(dbTask *DbTask) error {
 		return h.CreateUser(dbTask)
 	case db.DbActionCreate:
 		return h.CreateDatabase(dbTask)
-	case db.DbActionBackup:
+	case db.DbActionBackup, db.DbActionDailyBackup:
 		return h.BackupDb(dbTask)
 	case db.DbActionRestore:
 		return h.RestoreDb(dbTask)
 	case db.DbActionWaitReady:
 		return h.WaitReadyDb(dbTask)
+	case db.DbActionDrop:
+		return h.DropDatabase(dbTask)
+	case db.DbActionRollback:
+		return h.Rollback(dbTask)
+	case db.DbActionPruneBackups:
+		return h.PruneBackups(dbTask)
+	case db.DbActionVerifyBackup:
+		return h.VerifyBackup(dbTask)
+	case db.DbActionBaseBackup:
+		return h.BaseBackup(dbTask)
+	case db.DbActionRestoreToTimestamp:
+		return h.RestoreToTimestamp(dbTask)
+	case db.DbActionReplicate:
+		return h.Replicate(dbTask)
+	case db.DbActionWaitReplication:
+		return h.WaitReplication(dbTask)
+	case db.DbActionCutover:
+		return h.Cutover(dbTask)
 	default:
 		return fmt.Errorf("invalid db action %s", dbTask.Action)
 	}
@@ -73,18 +110,31 @@ func (h *DbTaskHandler) Init(setter server.GlobalSetter) (err error) {
 	setter.Set(constants.AgentKeyDbApi, h.DbApi)
 	setter.Set(constants.AgentKeyConnCtx, h.DbApi.ConnCtx)
 
+	h.Storage, err = storage.New(&h.BackupConfig.Storage, h.DbConfig.BackupRootPath)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create backup storage")
+		return err
+	}
+
 	return nil
 }
 
 func (h *DbTaskHandler) PostInit(getter server.GlobalGetter) error {
 	h.DbApi.DbStatusNotifier = getter.Get(constants.AgentKeyNotifyDbStatusProducer).(server.Producer)
 	h.jobProducer = getter.Get(constants.AgentKeyJobProducer).(server.Producer)
+	h.backupNotifier = getter.Get(constants.AgentKeyNotifyBackupProducer).(server.Producer)
+	h.DbApi.BackupNotifier = h.backupNotifier
+	h.DbApi.JobStatusNotifier = getter.Get(constants.AgentKeyNotifyJobStatusProducer).(server.Producer)
 	quitCtx := getter.Get(constants.AgentKeyQuitCtx).(context.Context)
 
 	if err := h.DbApi.MigrateDB(quitCtx); err != nil {
 		return err
 	}
 
+	if err := h.syncBackupCatalog(); err != nil {
+		log.Warn().Err(err).Msg("Failed to sync the backup catalog")
+	}
+
 	if err := h.recoverJobs(); err != nil {
 		return err
 	}
@@ -93,7 +143,13 @@ func (h *DbTaskHandler) PostInit(getter server.GlobalGetter) error {
 }
 
 func setFinalTaskStatus(api *db.DbApi, task *DbTask, err error) {
-	if err != nil {
+	if reason, interrupted := task.interrupted(); err != nil && interrupted {
+		task.Status = db.DbTaskStatusCancelled
+		task.Data.ErrReason = reason
+	} else if timeout, timedOut := task.timedOut(); err != nil && timedOut {
+		task.Status = db.DbTaskStatusFailed
+		task.Data.ErrReason = fmt.Sprintf("task is timed out after %s: %s", timeout, err)
+	} else if err != nil {
 		task.Status = db.DbTaskStatusFailed
 		task.Data.ErrReason = err.Error()
 	} else {
@@ -126,10 +182,33 @@ func (h *DbTaskHandler) recoverJobs() error {
 		return err
 	}
 
+	recovered := make(map[uuid.UUID]bool)
 	for i := range dbs {
 		if err := h.recoverJob(dbs[i].LastJobID, dbs[i].Name); err != nil {
 			return err
 		}
+		recovered[dbs[i].LastJobID] = true
+	}
+
+	// Jobs that do not change the db stage (e.g. daily backup)
+	// are not the last job of the db, recover them from the active tasks.
+	var tasks []db.DbTask
+	err = h.DbApi.Query(func(q *db.Queries) error {
+		tasks, err = q.ListActiveDbTasks(h.DbApi.ConnCtx)
+		return err
+	})
+	if err != nil && err != pgx.ErrNoRows {
+		return err
+	}
+
+	for i := range tasks {
+		if recovered[tasks[i].JobID] {
+			continue
+		}
+		if err := h.recoverJob(tasks[i].JobID, tasks[i].DbName); err != nil {
+			return err
+		}
+		recovered[tasks[i].JobID] = true
 	}
 	return nil
 }
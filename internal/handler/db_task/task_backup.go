@@ -7,16 +7,18 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	config "github.com/a-light-win/pg-helper/internal/config/agent"
 	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/storage"
 	"github.com/a-light-win/pg-helper/pkg/proto"
 	"github.com/rs/zerolog/log"
 )
 
 func (h *DbTaskHandler) BackupDb(task *DbTask) (err error) {
 	// Ensuere backup dir is exists
-	os.MkdirAll(h.DbConfig.BackupDbDir(task.DbName), 0750)
+	os.MkdirAll(h.DbConfig.BackupDbDir(task.SourceDbName()), 0750)
 
 	log.Info().Str("DbName", task.DbName).
 		Msg("Start to backup database")
@@ -25,8 +27,6 @@ func (h *DbTaskHandler) BackupDb(task *DbTask) (err error) {
 	h.DbApi.UpdateTaskStatus(task.DbTask, nil)
 	defer func() { setFinalTaskStatus(h.DbApi, task, err) }()
 
-	initial := task.Action != db.DbActionDailyBackup
-
 	db_, err := h.DbApi.GetDb(task.DbID, nil)
 	if err != nil {
 		log.Error().Err(err).
@@ -35,6 +35,10 @@ func (h *DbTaskHandler) BackupDb(task *DbTask) (err error) {
 		return err
 	}
 
+	// The initial backup is part of the migration,
+	// other backups (e.g. the safety backup before dropping) keep the db stage unchanged.
+	initial := task.Action == db.DbActionBackup && db_.ShouldBackup()
+
 	if task.Action == db.DbActionDailyBackup && !db_.IsReadyToUse() {
 		err := fmt.Errorf("db stage is not ready")
 		log.Error().Err(err).
@@ -45,7 +49,7 @@ func (h *DbTaskHandler) BackupDb(task *DbTask) (err error) {
 		return err
 	}
 
-	if initial && !db_.ShouldBackup() {
+	if !initial && !db_.CanBackup() {
 		err := fmt.Errorf("can not trigger backup in current db stage")
 		log.Error().Err(err).
 			Str("DbName", task.DbName).
@@ -62,16 +66,34 @@ func (h *DbTaskHandler) BackupDb(task *DbTask) (err error) {
 		defer func() { setFinalDbStatus(h.DbApi, db_, err) }()
 	}
 
-	// Backup the database here
+	// Backup the database here,
+	// the dump is compressed and encrypted in turn, each step writes a new temporary file.
+	encryption := &h.BackupConfig.Encryption
+	workDir, err := h.newWorkDir(".backup-", encryption.Enabled())
+	if err != nil {
+		log.Error().Err(err).
+			Str("DbName", task.DbName).
+			Msg("Failed to create the backup work dir")
+		return err
+	}
+	defer os.RemoveAll(workDir)
+	localPath := filepath.Join(workDir, "dump")
+
 	args := []string{
 		"-h", h.DbConfig.Host(&config.InstanceInfo{InstanceName: task.Data.BackupFrom}),
 		"-p", fmt.Sprint(h.DbConfig.Port),
 		"-U", h.DbConfig.User,
-		"-d", task.DbName,
-		"-f", task.Data.BackupPath + ".tmp",
+		"-d", task.SourceDbName(),
+		"-f", localPath,
+	}
+	switch backupFormat(task) {
+	case config.BackupFormatCustom:
+		args = append(args, "-Fc")
+	case config.BackupFormatDirectory:
+		args = append(args, "-Fd", "-j", fmt.Sprint(max(h.DbConfig.BackupJobs, 1)))
 	}
 
-	cmd := exec.Command("pg_dump", args...)
+	cmd := exec.CommandContext(task.Context(), "pg_dump", args...)
 	cmd.Dir = h.DbConfig.BackupRootPath
 	cmd.Stdin = strings.NewReader(h.DbConfig.Password + "\n")
 	var stdErr bytes.Buffer
@@ -84,17 +106,107 @@ func (h *DbTaskHandler) BackupDb(task *DbTask) (err error) {
 			Str("BackupPath", task.Data.BackupPath).
 			Str("StdErr", stdErr.String()).
 			Msg("Failed to backup database")
+		return err
+	}
+
+	compression := config.BackupCompressionOf(task.Data.BackupPath)
+	if compression != config.BackupCompressionNone {
+		compressed := filepath.Join(workDir, "compressed")
+		if err := compressFile(localPath, compressed, compression); err != nil {
+			log.Error().Err(err).
+				Str("DbName", task.DbName).
+				Str("BackupPath", task.Data.BackupPath).
+				Str("Compression", compression).
+				Msg("Failed to compress the backup")
+			return err
+		}
+		localPath = compressed
+	}
+
+	if encryption.Enabled() {
+		encrypted := filepath.Join(h.DbConfig.BackupRootPath, task.Data.BackupPath+".encrypted.tmp")
+		defer os.RemoveAll(encrypted)
+		if err := encryptPath(localPath, encrypted, encryption.AgeRecipient()); err != nil {
+			log.Error().Err(err).
+				Str("DbName", task.DbName).
+				Str("BackupPath", task.Data.BackupPath).
+				Msg("Failed to encrypt the backup")
+			return err
+		}
+		localPath = encrypted
+	}
+
+	manifest, err := h.newBackupManifest(task, localPath, compression)
+	if err != nil {
+		log.Error().Err(err).
+			Str("DbName", task.DbName).
+			Str("BackupPath", task.Data.BackupPath).
+			Msg("Failed to create the backup manifest")
+		return err
+	}
 
-		os.Remove(filepath.Join(h.DbConfig.BackupRootPath, task.Data.BackupPath+".tmp"))
+	if err := h.Storage.Save(task.Context(), localPath, task.Data.BackupPath); err != nil {
+		log.Error().Err(err).
+			Str("DbName", task.DbName).
+			Str("BackupPath", task.Data.BackupPath).
+			Msg("Failed to save the backup to storage")
 		return err
 	}
 
-	os.Rename(filepath.Join(h.DbConfig.BackupRootPath, task.Data.BackupPath+".tmp"),
-		filepath.Join(h.DbConfig.BackupRootPath, task.Data.BackupPath))
+	// The manifest is saved at last, a backup without manifest is incomplete.
+	if err := storage.SaveManifest(task.Context(), h.Storage, h.DbConfig.BackupRootPath, manifest); err != nil {
+		log.Error().Err(err).
+			Str("DbName", task.DbName).
+			Str("BackupPath", task.Data.BackupPath).
+			Msg("Failed to save the backup manifest")
+		return err
+	}
+
+	// The backup is already in the storage, failing to catalog it should not fail the task,
+	// it will be cataloged again on the next startup.
+	h.DbApi.AddBackup(manifest, nil)
 
 	log.Log().Str("DbName", task.DbName).
 		Str("BackupPath", task.Data.BackupPath).
+		Int64("Size", manifest.Size).
 		Msg("Database backup completed")
 
 	return nil
 }
+
+func (h *DbTaskHandler) newBackupManifest(task *DbTask, localPath string, compression string) (*config.BackupManifest, error) {
+	size, checksum, err := config.BackupChecksum(localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := exec.Command("pg_dump", "--version").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &config.BackupManifest{
+		BackupPath:     task.Data.BackupPath,
+		DbName:         task.SourceDbName(),
+		Format:         backupFormat(task),
+		Compression:    compression,
+		Size:           size,
+		Sha256:         checksum,
+		PgDumpVersion:  strings.TrimSpace(string(version)),
+		SourceInstance: task.Data.BackupFrom,
+		PgMajor:        h.DbConfig.CurrentVersion,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if h.BackupConfig.Encryption.Enabled() {
+		manifest.Encryption = config.BackupEncryptionAge
+		manifest.KeyFingerprint = h.BackupConfig.Encryption.Fingerprint()
+	}
+	return manifest, nil
+}
+
+func backupFormat(task *DbTask) string {
+	if task.Data.BackupFormat != "" {
+		return task.Data.BackupFormat
+	}
+	return config.BackupFormatOf(task.Data.BackupPath)
+}
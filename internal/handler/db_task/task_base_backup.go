@@ -0,0 +1,315 @@
+package db_task
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	config "github.com/a-light-win/pg-helper/internal/config/agent"
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// BaseBackup takes a physical base backup of the instance by pg_basebackup,
+// the WAL segments are not included, they are read from the WAL archive on recovery.
+func (h *DbTaskHandler) BaseBackup(task *DbTask) (err error) {
+	pitr := &h.BackupConfig.Pitr
+	log := log.With().
+		Str("Action", string(task.Action)).
+		Str("BackupPath", task.Data.BackupPath).
+		Logger()
+
+	task.Status = db.DbTaskStatusRunning
+	h.DbApi.UpdateTaskStatus(task.DbTask, nil)
+	defer func() { setFinalTaskStatus(h.DbApi, task, err) }()
+
+	if !pitr.Enabled {
+		return errors.New("point-in-time recovery is disabled")
+	}
+
+	backupDir := filepath.Join(pitr.BaseBackupRootPath, filepath.FromSlash(task.Data.BackupPath))
+	tmpDir := backupDir + ".tmp"
+	os.RemoveAll(tmpDir)
+	if err := os.MkdirAll(filepath.Dir(tmpDir), 0750); err != nil {
+		log.Error().Err(err).Msg("Failed to create the base backup dir")
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	startedAt := time.Now().UTC()
+	args := []string{
+		"-h", h.DbConfig.Host(nil),
+		"-p", fmt.Sprint(h.DbConfig.Port),
+		"-U", h.DbConfig.User,
+		"-D", tmpDir,
+		"-Fp",
+		"-X", "none",
+		"-c", "fast",
+	}
+
+	cmd := exec.CommandContext(task.Context(), "pg_basebackup", args...)
+	cmd.Stdin = strings.NewReader(h.DbConfig.Password + "\n")
+	var stdErr bytes.Buffer
+	cmd.Stderr = &stdErr
+
+	if err := cmd.Run(); err != nil {
+		log.Error().Err(err).
+			Strs("Args", args).
+			Str("StdErr", stdErr.String()).
+			Msg("Failed to take the base backup")
+		return err
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "backup_label"))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read the backup label")
+		return err
+	}
+	label, err := config.ParseBackupLabel(string(content))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to parse the backup label")
+		return err
+	}
+
+	walRange := h.readWalRange(tmpDir, label)
+
+	size, err := dirSize(tmpDir)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpDir, backupDir); err != nil {
+		log.Error().Err(err).Msg("Failed to save the base backup")
+		return err
+	}
+
+	err = h.DbApi.Query(func(q *db.Queries) error {
+		_, err := q.CreateBaseBackup(h.DbApi.ConnCtx, db.CreateBaseBackupParams{
+			Path:             task.Data.BackupPath,
+			Timeline:         walRange.Timeline,
+			StartWalLocation: label.StartWalLocation,
+			StartWalFile:     label.StartWalFile,
+			StopWalLocation:  walRange.StopWalLocation,
+			StopWalFile:      walRange.StopWalFile,
+			Size:             size,
+			StartedAt:        pgtype.Timestamp{Time: startedAt, Valid: true},
+			FinishedAt:       pgtype.Timestamp{Time: time.Now().UTC(), Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to add the base backup to catalog")
+		os.RemoveAll(backupDir)
+		return err
+	}
+
+	log.Log().Str("StartWalFile", label.StartWalFile).
+		Str("StopWalFile", walRange.StopWalFile).
+		Int64("Size", size).
+		Msg("Base backup completed")
+
+	h.pruneBaseBackups()
+
+	window := RecoveryWindow(h.DbApi, pitr)
+	h.backupNotifier.Send(window)
+	log.Info().Bool("Available", window.Available).
+		Time("Start", window.StartTime.AsTime()).
+		Time("End", window.EndTime.AsTime()).
+		Msg("Current recovery window")
+	return nil
+}
+
+type baseBackupWalRange struct {
+	config.WalRange
+	StopWalFile string
+}
+
+// readWalRange reads the WAL range that the base backup needs from its backup_manifest,
+// the stop WAL file is empty if the manifest is not written, e.g. by pg_basebackup before 13.
+func (h *DbTaskHandler) readWalRange(backupDir string, label *config.BackupLabel) *baseBackupWalRange {
+	walRange := &baseBackupWalRange{}
+	content, err := os.ReadFile(filepath.Join(backupDir, "backup_manifest"))
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read the backup manifest, the WAL range is unknown")
+		return walRange
+	}
+	parsed, err := config.ParseBackupManifest(content)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to parse the backup manifest, the WAL range is unknown")
+		return walRange
+	}
+	walRange.WalRange = *parsed
+
+	err = h.DbApi.Query(func(q *db.Queries) error {
+		var err error
+		walRange.StopWalFile, err = q.GetWalFileName(h.DbApi.ConnCtx, parsed.StopWalLocation)
+		return err
+	})
+	if err != nil {
+		log.Warn().Err(err).
+			Str("StopWalLocation", parsed.StopWalLocation).
+			Msg("Failed to get the stop WAL file of the base backup")
+		walRange.StopWalFile = ""
+	}
+	if walRange.StopWalFile != "" && walRange.StopWalFile < label.StartWalFile {
+		walRange.StopWalFile = label.StartWalFile
+	}
+	return walRange
+}
+
+// pruneBaseBackups removes the base backups that exceed PitrConfig.KeepBaseBackups,
+// and the WAL segments that are older than the oldest kept base backup.
+func (h *DbTaskHandler) pruneBaseBackups() {
+	pitr := &h.BackupConfig.Pitr
+
+	var backups []db.BaseBackup
+	err := h.DbApi.Query(func(q *db.Queries) error {
+		var err error
+		backups, err = q.ListBaseBackups(h.DbApi.ConnCtx)
+		if err != nil {
+			return err
+		}
+
+		keep := max(pitr.KeepBaseBackups, 1)
+		for len(backups) > keep {
+			backup := backups[len(backups)-1]
+			if err := os.RemoveAll(filepath.Join(pitr.BaseBackupRootPath, filepath.FromSlash(backup.Path))); err != nil {
+				return err
+			}
+			if err := q.DeleteBaseBackup(h.DbApi.ConnCtx, backup.ID); err != nil {
+				return err
+			}
+			log.Info().Str("BackupPath", backup.Path).Msg("Base backup pruned")
+			backups = backups[:len(backups)-1]
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to prune base backups")
+		return
+	}
+	if len(backups) == 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(pitr.WalArchivePath)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read the WAL archive")
+		return
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	oldest := backups[len(backups)-1]
+	pruned := 0
+	for _, name := range config.WalFilesToPrune(names, oldest.StartWalFile) {
+		if err := os.Remove(filepath.Join(pitr.WalArchivePath, name)); err != nil {
+			log.Warn().Err(err).Str("WalFile", name).Msg("Failed to prune WAL file")
+			continue
+		}
+		pruned++
+	}
+	log.Info().Int("Pruned", pruned).
+		Str("StartWalFile", oldest.StartWalFile).
+		Msg("WAL archive pruned")
+}
+
+// RecoveryWindow returns the time range that the instance can be recovered to,
+// from the end of the oldest base backup whose WAL range is archived
+// to the latest archived WAL segment.
+func RecoveryWindow(dbApi *db.DbApi, pitr *config.PitrConfig) *proto.RecoveryWindow {
+	window := &proto.RecoveryWindow{InstanceName: dbApi.DbConfig.InstanceName}
+
+	var backups []db.BaseBackup
+	err := dbApi.Query(func(q *db.Queries) error {
+		var err error
+		backups, err = q.ListBaseBackups(dbApi.ConnCtx)
+		return err
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list base backups")
+		return window
+	}
+	for _, backup := range backups {
+		window.BaseBackups = append(window.BaseBackups, &proto.BaseBackup{
+			Path:         backup.Path,
+			Timeline:     backup.Timeline,
+			StartWalFile: backup.StartWalFile,
+			StopWalFile:  backup.StopWalFile,
+			Size:         backup.Size,
+			StartedAt:    timestamppb.New(backup.StartedAt.Time),
+			FinishedAt:   timestamppb.New(backup.FinishedAt.Time),
+		})
+	}
+
+	entries, err := os.ReadDir(pitr.WalArchivePath)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read the WAL archive")
+		return window
+	}
+	var endTime time.Time
+	for _, entry := range entries {
+		if !config.IsWalSegment(entry.Name()) || entry.Name() < window.EndWalFile {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		window.EndWalFile = entry.Name()
+		endTime = info.ModTime().UTC()
+	}
+	if window.EndWalFile == "" {
+		return window
+	}
+
+	// The backups are ordered by the finished time desc,
+	// the oldest one that is consistent with the archived WAL starts the window.
+	for i := len(backups) - 1; i >= 0; i-- {
+		backup := backups[i]
+		stopWalFile := backup.StopWalFile
+		if stopWalFile == "" {
+			stopWalFile = backup.StartWalFile
+		}
+		if stopWalFile > window.EndWalFile || backup.StartWalFile > window.EndWalFile {
+			continue
+		}
+
+		window.Available = true
+		window.StartWalFile = backup.StartWalFile
+		window.StartTime = timestamppb.New(backup.FinishedAt.Time)
+		if endTime.Before(backup.FinishedAt.Time) {
+			endTime = backup.FinishedAt.Time
+		}
+		window.EndTime = timestamppb.New(endTime)
+		break
+	}
+	return window
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
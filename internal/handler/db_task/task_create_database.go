@@ -55,7 +55,7 @@ func (h *DbTaskHandler) createDatabase(task *DbTask, q *db.Queries) (err error)
 
 	// Create database
 	conn := q.Conn()
-	_, err = conn.Exec(h.DbApi.ConnCtx, fmt.Sprintf("CREATE DATABASE %s OWNER %s",
+	_, err = conn.Exec(task.Context(), fmt.Sprintf("CREATE DATABASE %s OWNER %s",
 		task.DbName, task.Data.Owner))
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to create database")
@@ -19,7 +19,7 @@ func (h *DbTaskHandler) CreateUser(task *DbTask) (err error) {
 }
 
 func (h *DbTaskHandler) createUser(task *DbTask, q *db.Queries) (err error) {
-	connCtx := h.DbApi.ConnCtx
+	connCtx := task.Context()
 	log := log.With().
 		Str("DbName", task.DbName).
 		Str("Owner", task.Data.Owner).
@@ -0,0 +1,109 @@
+package db_task
+
+import (
+	"fmt"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func (h *DbTaskHandler) DropDatabase(task *DbTask) error {
+	return h.DbApi.Query(func(q *db.Queries) error {
+		return h.dropDatabase(task, q)
+	})
+}
+
+func (h *DbTaskHandler) dropDatabase(task *DbTask, q *db.Queries) (err error) {
+	connCtx := task.Context()
+	log := log.With().
+		Str("DbName", task.DbName).
+		Str("Action", string(task.Action)).
+		Logger()
+
+	task.Status = db.DbTaskStatusRunning
+	h.DbApi.UpdateTaskStatus(task.DbTask, q)
+	defer func() { setFinalTaskStatus(h.DbApi, task, err) }()
+
+	db_, err := h.DbApi.GetDb(task.DbID, q)
+	if err != nil {
+		log.Error().Err(err).
+			Msg("Can not drop database due to db error")
+		return logger.NewAlreadyLoggedError(err, zerolog.ErrorLevel)
+	}
+
+	if !db_.CanDrop() {
+		err = fmt.Errorf("db stage is not idle")
+		log.Error().Err(err).
+			Str("Stage", db_.Stage.String()).
+			Str("Status", db_.Status.String()).
+			Msg("Can not drop database")
+		return logger.NewAlreadyLoggedError(err, zerolog.ErrorLevel)
+	}
+
+	db_.Stage = proto.DbStage_DropDatabase
+	db_.Status = proto.DbStatus_Processing
+	h.DbApi.UpdateDbStatus(db_, q)
+
+	defer func() { setFinalDbStatus(h.DbApi, db_, err) }()
+
+	terminated, err := q.TerminateDbConnections(connCtx, pgtype.Text{String: task.DbName, Valid: true})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to terminate connections of the database")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+	log.Info().Int64("Terminated", terminated).Msg("Connections of the database are terminated")
+
+	conn := q.Conn()
+	// FORCE prevents the clients that reconnect in the meantime from blocking the drop
+	_, err = conn.Exec(connCtx, fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", task.DbName))
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to drop database")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	log.Log().Msg("Database dropped successfully")
+
+	if task.Data.DropOwner {
+		// The database is already gone, failing to drop the owner
+		// should not mark the database as failed.
+		h.dropOwner(task, q)
+	}
+	return nil
+}
+
+func (h *DbTaskHandler) dropOwner(task *DbTask, q *db.Queries) {
+	connCtx := task.Context()
+	log := log.With().
+		Str("DbName", task.DbName).
+		Str("Owner", task.Data.Owner).
+		Logger()
+
+	if task.Data.Owner == "" || task.Data.Owner == h.DbConfig.User {
+		log.Debug().Msg("Skip dropping the owner")
+		return
+	}
+
+	owner := pgtype.Text{String: task.Data.Owner, Valid: true}
+	count, err := q.CountDbsOwnedBy(connCtx, owner)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to count databases owned by the owner")
+		return
+	}
+	if count > 0 {
+		log.Info().Int64("OwnedDbs", count).
+			Msg("Owner still owns other databases, keep it")
+		return
+	}
+
+	conn := q.Conn()
+	if _, err := conn.Exec(connCtx, fmt.Sprintf("DROP ROLE IF EXISTS %s", task.Data.Owner)); err != nil {
+		log.Warn().Err(err).Msg("Failed to drop the owner")
+		return
+	}
+
+	log.Log().Msg("Owner dropped successfully")
+}
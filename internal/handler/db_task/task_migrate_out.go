@@ -32,7 +32,7 @@ func (h *DbTaskHandler) migrateOut(task *DbTask, q *db.Queries) (err error) {
 		return logger.NewAlreadyLoggedError(err, zerolog.ErrorLevel)
 	}
 
-	if !db_.IsReadyToUse() {
+	if !db_.CanMigrateOut() {
 		err := fmt.Errorf("db stage is not ready")
 		log.Error().Err(err).
 			Str("DbName", task.DbName).
@@ -0,0 +1,122 @@
+package db_task
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	config "github.com/a-light-win/pg-helper/internal/config/agent"
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/rs/zerolog/log"
+)
+
+func (h *DbTaskHandler) PruneBackups(task *DbTask) (err error) {
+	log := log.With().
+		Str("DbName", task.DbName).
+		Str("Action", string(task.Action)).
+		Logger()
+
+	task.Status = db.DbTaskStatusRunning
+	h.DbApi.UpdateTaskStatus(task.DbTask, nil)
+	defer func() { setFinalTaskStatus(h.DbApi, task, err) }()
+
+	backups, err := h.listBackups(task.DbName)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list backups")
+		return err
+	}
+
+	protected, err := h.backupsInUse()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load backups in use")
+		return err
+	}
+
+	task.Data.PrunedBackups = nil
+	for _, backup := range h.BackupConfig.BackupsToPrune(backups, time.Now()) {
+		if protected[backup.Path] {
+			log.Info().Str("BackupPath", backup.Path).
+				Msg("Backup is in use, skip pruning")
+			continue
+		}
+
+		if err := h.Storage.Remove(task.Context(), backup.Path); err != nil {
+			log.Warn().Err(err).
+				Str("BackupPath", backup.Path).
+				Msg("Failed to prune backup")
+			continue
+		}
+
+		if err := h.Storage.Remove(task.Context(), config.BackupManifestPath(backup.Path)); err != nil {
+			log.Warn().Err(err).
+				Str("BackupPath", backup.Path).
+				Msg("Failed to prune the backup manifest")
+		}
+		h.DbApi.RemoveBackup(backup.Path, nil)
+
+		log.Info().Str("BackupPath", backup.Path).
+			Time("CreatedAt", backup.CreatedAt).
+			Msg("Backup pruned")
+		task.Data.PrunedBackups = append(task.Data.PrunedBackups, backup.Path)
+	}
+
+	log.Log().Int("Pruned", len(task.Data.PrunedBackups)).
+		Msg("Prune backups completed")
+
+	return h.DbApi.UpdateTaskData(task.DbTask, nil)
+}
+
+func (h *DbTaskHandler) listBackups(dbName string) ([]config.BackupFile, error) {
+	relDir := h.DbConfig.BackupDbRelDir(dbName)
+	objects, err := h.Storage.List(h.DbApi.ConnCtx, relDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []config.BackupFile
+	for _, object := range objects {
+		// The backup is still in progress
+		if strings.HasSuffix(object.Key, ".tmp") {
+			continue
+		}
+		// The manifest is pruned together with its backup
+		if strings.HasSuffix(object.Key, config.BackupManifestExt) {
+			continue
+		}
+
+		createdAt, err := h.DbConfig.BackupCreatedAt(object.Key)
+		if err != nil {
+			log.Debug().Str("DbName", dbName).
+				Str("File", object.Key).
+				Msg("Skip the file that is not a backup")
+			continue
+		}
+		backups = append(backups, config.BackupFile{
+			Path:      object.Key,
+			CreatedAt: createdAt,
+		})
+	}
+	return backups, nil
+}
+
+// The backups that are referenced by the pending or running restore or verify tasks
+func (h *DbTaskHandler) backupsInUse() (map[string]bool, error) {
+	var tasks []db.DbTask
+	err := h.DbApi.Query(func(q *db.Queries) error {
+		var err error
+		tasks, err = q.ListActiveDbTasks(h.DbApi.ConnCtx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inUse := make(map[string]bool)
+	for _, task := range tasks {
+		if (task.Action == db.DbActionRestore || task.Action == db.DbActionVerifyBackup) &&
+			task.Data.BackupPath != "" {
+			inUse[filepath.Clean(task.Data.BackupPath)] = true
+		}
+	}
+	return inUse, nil
+}
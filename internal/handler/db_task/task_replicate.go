@@ -0,0 +1,427 @@
+package db_task
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	config "github.com/a-light-win/pg-helper/internal/config/agent"
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// Replicate copies the schema from the old pg instance,
+// then subscribes to all tables of the old database by logical replication.
+// The database keeps the ReplicateDatabase stage until the cutover is done.
+func (h *DbTaskHandler) Replicate(task *DbTask) (err error) {
+	log := log.With().
+		Str("DbName", task.DbName).
+		Str("ReplicateFrom", task.Data.ReplicateFrom).
+		Logger()
+	log.Info().Msg("Start to replicate database")
+
+	task.Status = db.DbTaskStatusRunning
+	h.DbApi.UpdateTaskStatus(task.DbTask, nil)
+	defer func() { setFinalTaskStatus(h.DbApi, task, err) }()
+
+	db_, err := h.DbApi.GetDb(task.DbID, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Can not replicate database due to db error")
+		return err
+	}
+
+	if !db_.CanReplicate() {
+		err := errors.New("db stage is not ready")
+		log.Error().Err(err).
+			Str("Stage", db_.Stage.String()).
+			Str("Status", db_.Status.String()).
+			Msg("Failed to replicate database")
+		return err
+	}
+	retry := db_.Stage == proto.DbStage_ReplicateDatabase
+
+	db_.Stage = proto.DbStage_ReplicateDatabase
+	db_.Status = proto.DbStatus_Processing
+	h.DbApi.UpdateDbStatus(db_, nil)
+
+	defer func() {
+		if err != nil {
+			setFinalDbStatus(h.DbApi, db_, err)
+		}
+	}()
+
+	oldConn, newConn, err := h.connectReplication(task)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to connect to the databases")
+		return err
+	}
+	defer oldConn.Close(h.DbApi.ConnCtx)
+
+	// A failed replication may leave the subscription and the copied data behind
+	if err := h.dropReplication(task, oldConn, newConn); err != nil {
+		newConn.Close(h.DbApi.ConnCtx)
+		return err
+	}
+	if retry {
+		newConn.Close(h.DbApi.ConnCtx)
+		if err := h.recreateDb(task, db_.Owner); err != nil {
+			return err
+		}
+		if newConn, err = pgx.Connect(task.Context(), h.DbConfig.Url(task.DbName, nil)); err != nil {
+			log.Warn().Err(err).Msg("Failed to connect to the database")
+			return err
+		}
+	}
+	defer newConn.Close(h.DbApi.ConnCtx)
+
+	if err := h.copySchema(task); err != nil {
+		return err
+	}
+
+	name := pgx.Identifier{replicationName(task.DbName)}.Sanitize()
+	if _, err := oldConn.Exec(task.Context(),
+		fmt.Sprintf("CREATE PUBLICATION %s FOR ALL TABLES", name)); err != nil {
+		log.Warn().Err(err).Msg("Failed to create the publication")
+		return err
+	}
+
+	// The subscription connects to the old pg instance from the new one,
+	// so the host template must be resolvable by the pg instance too.
+	connInfo := h.DbConfig.Url(task.DbName, &config.InstanceInfo{InstanceName: task.Data.ReplicateFrom})
+	if _, err := newConn.Exec(task.Context(),
+		fmt.Sprintf("CREATE SUBSCRIPTION %s CONNECTION '%s' PUBLICATION %s",
+			name, strings.ReplaceAll(connInfo, "'", "''"), name)); err != nil {
+		log.Warn().Err(err).Msg("Failed to create the subscription")
+		return err
+	}
+
+	log.Info().Msg("Database replication is started")
+	return nil
+}
+
+// WaitReplication waits until the initial data copy is done
+// and the replication lag is small enough to cutover.
+func (h *DbTaskHandler) WaitReplication(task *DbTask) (err error) {
+	log := log.With().
+		Str("DbName", task.DbName).
+		Str("ReplicateFrom", task.Data.ReplicateFrom).
+		Logger()
+
+	task.Status = db.DbTaskStatusRunning
+	h.DbApi.UpdateTaskStatus(task.DbTask, nil)
+	defer func() { setFinalTaskStatus(h.DbApi, task, err) }()
+
+	db_, err := h.DbApi.GetDb(task.DbID, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Can not wait replication due to db error")
+		return err
+	}
+
+	if !db_.IsReplicating() {
+		err := errors.New("db is not replicating")
+		log.Error().Err(err).
+			Str("Stage", db_.Stage.String()).
+			Str("Status", db_.Status.String()).
+			Msg("Failed to wait replication")
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			setFinalDbStatus(h.DbApi, db_, err)
+		}
+	}()
+
+	oldConn, newConn, err := h.connectReplication(task)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to connect to the databases")
+		return err
+	}
+	defer oldConn.Close(h.DbApi.ConnCtx)
+	defer newConn.Close(h.DbApi.ConnCtx)
+
+	name := replicationName(task.DbName)
+	err = h.waitUntil(task, h.DbConfig.ReplicationTimeout, func() (bool, error) {
+		var pending int64
+		if err := newConn.QueryRow(task.Context(),
+			`SELECT COUNT(*) FROM pg_catalog.pg_subscription_rel sr
+			JOIN pg_catalog.pg_subscription s ON s.oid = sr.srsubid
+			WHERE s.subname = $1 AND sr.srsubstate <> 'r'`, name).Scan(&pending); err != nil {
+			return false, err
+		}
+		if pending > 0 {
+			log.Debug().Int64("PendingTables", pending).Msg("Initial data copy is in progress")
+			return false, nil
+		}
+
+		var lag pgtype.Int8
+		if err := oldConn.QueryRow(task.Context(),
+			`SELECT pg_wal_lsn_diff(pg_current_wal_lsn(), confirmed_flush_lsn)::bigint
+			FROM pg_catalog.pg_replication_slots WHERE slot_name = $1`, name).Scan(&lag); err != nil {
+			return false, err
+		}
+		log.Debug().Int64("Lag", lag.Int64).Bool("Valid", lag.Valid).Msg("Replication lag")
+		return lag.Valid && lag.Int64 <= h.DbConfig.ReplicationMaxLag, nil
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to wait the replication to catch up")
+		return err
+	}
+
+	log.Info().Msg("Database replication caught up")
+	return nil
+}
+
+// Cutover stops the writes to the old database, waits for the last changes to be replicated,
+// then moves the sequences forward and removes the replication.
+// The old database refuses new connections after the cutover, until it is rolled back.
+func (h *DbTaskHandler) Cutover(task *DbTask) (err error) {
+	log := log.With().
+		Str("DbName", task.DbName).
+		Str("ReplicateFrom", task.Data.ReplicateFrom).
+		Logger()
+	log.Info().Msg("Start to cutover database")
+
+	task.Status = db.DbTaskStatusRunning
+	h.DbApi.UpdateTaskStatus(task.DbTask, nil)
+	defer func() { setFinalTaskStatus(h.DbApi, task, err) }()
+
+	db_, err := h.DbApi.GetDb(task.DbID, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Can not cutover database due to db error")
+		return err
+	}
+
+	if !db_.IsReplicating() {
+		err := errors.New("db is not replicating")
+		log.Error().Err(err).
+			Str("Stage", db_.Stage.String()).
+			Str("Status", db_.Status.String()).
+			Msg("Failed to cutover database")
+		return err
+	}
+
+	defer func() { setFinalDbStatus(h.DbApi, db_, err) }()
+
+	oldConn, newConn, err := h.connectReplication(task)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to connect to the databases")
+		return err
+	}
+	defer oldConn.Close(h.DbApi.ConnCtx)
+	defer newConn.Close(h.DbApi.ConnCtx)
+
+	connCtx := task.Context()
+	dbName := pgx.Identifier{task.DbName}.Sanitize()
+	if _, err := oldConn.Exec(connCtx, fmt.Sprintf("ALTER DATABASE %s CONNECTION LIMIT 0", dbName)); err != nil {
+		log.Warn().Err(err).Msg("Failed to block the connections of the old database")
+		return err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		// The old database is still the one in use,
+		// the task context may be timed out already.
+		if _, err := oldConn.Exec(h.DbApi.ConnCtx, fmt.Sprintf("ALTER DATABASE %s CONNECTION LIMIT -1", dbName)); err != nil {
+			log.Error().Err(err).Msg("Failed to unblock the connections of the old database")
+		}
+	}()
+
+	// The replication runs in a walsender backend, it must not be terminated
+	var terminated int64
+	if err := oldConn.QueryRow(connCtx,
+		`SELECT COUNT(pg_terminate_backend(pid)) FROM pg_catalog.pg_stat_activity
+		WHERE datname = $1 AND pid <> pg_backend_pid() AND backend_type = 'client backend'`,
+		task.DbName).Scan(&terminated); err != nil {
+		log.Warn().Err(err).Msg("Failed to terminate connections of the old database")
+		return err
+	}
+	log.Info().Int64("Terminated", terminated).Msg("Connections of the old database are terminated")
+
+	var targetLsn string
+	if err := oldConn.QueryRow(connCtx, "SELECT pg_current_wal_lsn()::text").Scan(&targetLsn); err != nil {
+		return err
+	}
+
+	name := replicationName(task.DbName)
+	err = h.waitUntil(task, h.DbConfig.CutoverTimeout, func() (bool, error) {
+		var caughtUp pgtype.Bool
+		err := oldConn.QueryRow(connCtx,
+			`SELECT confirmed_flush_lsn >= $1::pg_lsn
+			FROM pg_catalog.pg_replication_slots WHERE slot_name = $2`, targetLsn, name).Scan(&caughtUp)
+		return caughtUp.Valid && caughtUp.Bool, err
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to wait the last changes to be replicated")
+		return err
+	}
+
+	if err := h.syncSequences(task, oldConn, newConn); err != nil {
+		return err
+	}
+
+	if err := h.dropReplication(task, oldConn, newConn); err != nil {
+		return err
+	}
+
+	log.Log().Msg("Database cutover completed")
+	return nil
+}
+
+func replicationName(dbName string) string {
+	return "pg_helper_" + dbName
+}
+
+// connectReplication connects to the database on the old pg instance and the local one
+func (h *DbTaskHandler) connectReplication(task *DbTask) (oldConn *pgx.Conn, newConn *pgx.Conn, err error) {
+	connCtx := task.Context()
+	oldConn, err = pgx.Connect(connCtx,
+		h.DbConfig.Url(task.DbName, &config.InstanceInfo{InstanceName: task.Data.ReplicateFrom}))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newConn, err = pgx.Connect(connCtx, h.DbConfig.Url(task.DbName, nil))
+	if err != nil {
+		oldConn.Close(connCtx)
+		return nil, nil, err
+	}
+	return oldConn, newConn, nil
+}
+
+// dropReplication drops the subscription and its replication slot, then the publication
+func (h *DbTaskHandler) dropReplication(task *DbTask, oldConn *pgx.Conn, newConn *pgx.Conn) error {
+	connCtx := task.Context()
+	name := pgx.Identifier{replicationName(task.DbName)}.Sanitize()
+
+	if _, err := newConn.Exec(connCtx, fmt.Sprintf("DROP SUBSCRIPTION IF EXISTS %s", name)); err != nil {
+		log.Warn().Err(err).
+			Str("DbName", task.DbName).
+			Msg("Failed to drop the subscription")
+		return err
+	}
+	if _, err := oldConn.Exec(connCtx, fmt.Sprintf("DROP PUBLICATION IF EXISTS %s", name)); err != nil {
+		log.Warn().Err(err).
+			Str("DbName", task.DbName).
+			Msg("Failed to drop the publication")
+		return err
+	}
+	return nil
+}
+
+// copySchema copies the schema of the database from the old pg instance,
+// logical replication only replicates the data.
+func (h *DbTaskHandler) copySchema(task *DbTask) error {
+	schemaFile, err := os.CreateTemp(h.DbConfig.BackupRootPath, ".schema-")
+	if err != nil {
+		log.Warn().Err(err).
+			Str("DbName", task.DbName).
+			Msg("Failed to create the schema file")
+		return err
+	}
+	schemaFile.Close()
+	defer os.Remove(schemaFile.Name())
+
+	err = h.runPgTool(task, "pg_dump",
+		"-h", h.DbConfig.Host(&config.InstanceInfo{InstanceName: task.Data.ReplicateFrom}),
+		"-p", fmt.Sprint(h.DbConfig.Port),
+		"-U", h.DbConfig.User,
+		"-d", task.DbName,
+		"--schema-only",
+		"-f", schemaFile.Name())
+	if err != nil {
+		return err
+	}
+
+	return h.runPgTool(task, "psql",
+		"-h", h.DbConfig.Host(nil),
+		"-p", fmt.Sprint(h.DbConfig.Port),
+		"-U", h.DbConfig.User,
+		"-d", task.DbName,
+		"-f", schemaFile.Name())
+}
+
+func (h *DbTaskHandler) runPgTool(task *DbTask, name string, args ...string) error {
+	cmd := exec.CommandContext(task.Context(), name, args...)
+	cmd.Dir = h.DbConfig.BackupRootPath
+	cmd.Stdin = strings.NewReader(h.DbConfig.Password + "\n")
+	var stdErr bytes.Buffer
+	cmd.Stderr = &stdErr
+
+	if err := cmd.Run(); err != nil {
+		log.Error().Err(err).
+			Strs("Args", args).
+			Str("DbName", task.DbName).
+			Str("StdErr", stdErr.String()).
+			Msgf("Failed to run %s", name)
+		return err
+	}
+	return nil
+}
+
+// syncSequences moves the sequences of the new database to the values of the old one,
+// logical replication does not replicate the sequences.
+func (h *DbTaskHandler) syncSequences(task *DbTask, oldConn *pgx.Conn, newConn *pgx.Conn) error {
+	connCtx := task.Context()
+	rows, err := oldConn.Query(connCtx,
+		`SELECT schemaname, sequencename, last_value FROM pg_catalog.pg_sequences
+		WHERE last_value IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+
+	type sequence struct {
+		Schema    string
+		Name      string
+		LastValue int64
+	}
+	sequences, err := pgx.CollectRows(rows, pgx.RowToStructByPos[sequence])
+	if err != nil {
+		return err
+	}
+
+	for _, seq := range sequences {
+		if _, err := newConn.Exec(connCtx,
+			"SELECT pg_catalog.setval(format('%I.%I', $1::text, $2::text)::regclass, $3)",
+			seq.Schema, seq.Name, seq.LastValue); err != nil {
+			log.Warn().Err(err).
+				Str("DbName", task.DbName).
+				Str("Sequence", seq.Schema+"."+seq.Name).
+				Msg("Failed to sync the sequence")
+			return err
+		}
+	}
+	return nil
+}
+
+// waitUntil checks every DbConfig.ReplicationCheckInterval until done returns true,
+// it fails if done is not true in timeout or the task is interrupted.
+func (h *DbTaskHandler) waitUntil(task *DbTask, timeout time.Duration, done func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(task.Context(), timeout)
+	defer cancel()
+
+	for {
+		ok, err := done()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(h.DbConfig.ReplicationCheckInterval):
+		}
+	}
+}
@@ -9,8 +9,12 @@ import (
 	"path/filepath"
 	"strings"
 
+	config "github.com/a-light-win/pg-helper/internal/config/agent"
 	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/storage"
 	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rs/zerolog/log"
 )
 
@@ -30,7 +34,8 @@ func (h *DbTaskHandler) RestoreDb(task *DbTask) (err error) {
 		return err
 	}
 
-	if !db_.CanRestore() {
+	inPlace := task.Data.InPlace
+	if (inPlace && !db_.CanRestoreInPlace()) || (!inPlace && !db_.CanRestore()) {
 		err := errors.New("db stage is not ready")
 		log.Error().Err(err).
 			Str("DbName", task.DbName).
@@ -40,29 +45,255 @@ func (h *DbTaskHandler) RestoreDb(task *DbTask) (err error) {
 		return err
 	}
 
+	if inPlace {
+		return h.restoreInPlace(task, db_)
+	}
+
+	// The failed restore may leave some objects in the database
+	retry := db_.Stage == proto.DbStage_RestoreDatabase && db_.Status == proto.DbStatus_Failed
+
 	db_.Stage = proto.DbStage_RestoreDatabase
 	db_.Status = proto.DbStatus_Processing
 	h.DbApi.UpdateDbStatus(db_, nil)
 
 	defer func() { setFinalDbStatus(h.DbApi, db_, err) }()
 
-	if _, err := os.Stat(filepath.Join(h.DbConfig.BackupRootPath, task.Data.BackupPath)); err != nil {
-		log.Warn().Err(err).
-			Str("DbName", task.DbName).
-			Str("BackupPath", task.Data.BackupPath).
-			Msg("Failed to restore database")
+	if retry {
+		if err := h.recreateDb(task, db_.Owner); err != nil {
+			return err
+		}
+	}
+
+	localPath, release, err := h.fetchBackup(task)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return h.restoreBackup(task, task.DbName, localPath)
+}
+
+// restoreInPlace restores the backup into a temporary database and swaps it with the in-use one,
+// the in-use database keeps ReadyToUse until the backup is fully restored,
+// so a broken or cancelled restore does not take it down.
+// The disk space of both databases is needed while restoring.
+func (h *DbTaskHandler) restoreInPlace(task *DbTask, db_ *db.Db) (err error) {
+	localPath, release, err := h.fetchBackup(task)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	restoreDb := fmt.Sprintf("pg_helper_restore_%s", task.ID.String()[:8])
+	if err := h.createRestoreDb(task, restoreDb, db_.Owner); err != nil {
 		return err
 	}
+	swapped := false
+	defer func() {
+		if !swapped {
+			h.dropScratchDb(restoreDb)
+		}
+	}()
 
+	if err := h.restoreBackup(task, restoreDb, localPath); err != nil {
+		return err
+	}
+
+	db_.Stage = proto.DbStage_RestoreDatabase
+	db_.Status = proto.DbStatus_Processing
+	h.DbApi.UpdateDbStatus(db_, nil)
+
+	defer func() { setFinalDbStatus(h.DbApi, db_, err) }()
+
+	if err := h.swapDb(task, restoreDb); err != nil {
+		return err
+	}
+	swapped = true
+	return nil
+}
+
+// createRestoreDb creates the empty database that the backup is restored into,
+// the one left by the previous attempt of the task is dropped first.
+func (h *DbTaskHandler) createRestoreDb(task *DbTask, name string, owner string) error {
+	connCtx := task.Context()
+	return h.DbApi.Query(func(q *db.Queries) error {
+		conn := q.Conn()
+		if _, err := conn.Exec(connCtx,
+			fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", pgx.Identifier{name}.Sanitize())); err != nil {
+			log.Warn().Err(err).Str("RestoreDb", name).Msg("Failed to drop the restore database")
+			return err
+		}
+		if _, err := conn.Exec(connCtx,
+			fmt.Sprintf("CREATE DATABASE %s OWNER %s",
+				pgx.Identifier{name}.Sanitize(), pgx.Identifier{owner}.Sanitize())); err != nil {
+			log.Warn().Err(err).Str("RestoreDb", name).Msg("Failed to create the restore database")
+			return err
+		}
+		return nil
+	})
+}
+
+// swapDb replaces the database of the task with the restored one
+func (h *DbTaskHandler) swapDb(task *DbTask, restoreDb string) error {
+	connCtx := task.Context()
+	log := log.With().
+		Str("DbName", task.DbName).
+		Str("RestoreDb", restoreDb).
+		Logger()
+
+	return h.DbApi.Query(func(q *db.Queries) error {
+		terminated, err := q.TerminateDbConnections(connCtx, pgtype.Text{String: task.DbName, Valid: true})
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to terminate connections of the database")
+			return err
+		}
+		log.Info().Int64("Terminated", terminated).Msg("Connections of the database are terminated")
+
+		// DROP DATABASE can not run in a transaction,
+		// the database is missing if the rename fails and the restore can be retried.
+		conn := q.Conn()
+		if _, err := conn.Exec(connCtx,
+			fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", pgx.Identifier{task.DbName}.Sanitize())); err != nil {
+			log.Warn().Err(err).Msg("Failed to drop database")
+			return err
+		}
+		if _, err := conn.Exec(connCtx,
+			fmt.Sprintf("ALTER DATABASE %s RENAME TO %s",
+				pgx.Identifier{restoreDb}.Sanitize(), pgx.Identifier{task.DbName}.Sanitize())); err != nil {
+			log.Warn().Err(err).Msg("Failed to rename the restored database")
+			return err
+		}
+
+		log.Info().Msg("Database is replaced by the restored one")
+		return nil
+	})
+}
+
+// recreateDb drops the database and creates an empty one with the same owner
+func (h *DbTaskHandler) recreateDb(task *DbTask, owner string) error {
+	connCtx := task.Context()
+	log := log.With().
+		Str("DbName", task.DbName).
+		Str("Action", string(task.Action)).
+		Logger()
+
+	return h.DbApi.Query(func(q *db.Queries) error {
+		terminated, err := q.TerminateDbConnections(connCtx, pgtype.Text{String: task.DbName, Valid: true})
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to terminate connections of the database")
+			return err
+		}
+		log.Info().Int64("Terminated", terminated).Msg("Connections of the database are terminated")
+
+		conn := q.Conn()
+		if _, err := conn.Exec(connCtx,
+			fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", pgx.Identifier{task.DbName}.Sanitize())); err != nil {
+			log.Warn().Err(err).Msg("Failed to drop database")
+			return err
+		}
+
+		if _, err := conn.Exec(connCtx,
+			fmt.Sprintf("CREATE DATABASE %s OWNER %s",
+				pgx.Identifier{task.DbName}.Sanitize(), pgx.Identifier{owner}.Sanitize())); err != nil {
+			log.Warn().Err(err).Msg("Failed to create database")
+			return err
+		}
+
+		log.Info().Msg("Database is recreated for restoring")
+		return nil
+	})
+}
+
+// fetchBackup fetches the backup of the task from the storage,
+// verifies it against the manifest, then decrypts and decompresses it if needed.
+// release must be called to clean the local files when the backup is no longer used.
+func (h *DbTaskHandler) fetchBackup(task *DbTask) (localPath string, release func(), err error) {
+	log := log.With().
+		Str("DbName", task.DbName).
+		Str("BackupPath", task.Data.BackupPath).
+		Logger()
+
+	localPath, releaseFetched, err := h.Storage.Fetch(task.Context(), task.Data.BackupPath)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to fetch the backup")
+		return "", nil, err
+	}
+
+	manifest, err := h.verifyBackup(task, localPath)
+	if err != nil {
+		releaseFetched()
+		log.Warn().Err(err).Msg("Failed to verify the backup")
+		return "", nil, err
+	}
+
+	encrypted := manifest != nil && manifest.Encryption != ""
+	workDir, err := h.newWorkDir(".restore-", encrypted)
+	if err != nil {
+		releaseFetched()
+		log.Warn().Err(err).Msg("Failed to create the restore work dir")
+		return "", nil, err
+	}
+	release = func() {
+		os.RemoveAll(workDir)
+		releaseFetched()
+	}
+	defer func() {
+		if err != nil {
+			release()
+		}
+	}()
+
+	if encrypted {
+		identity, err := h.BackupConfig.Encryption.Identity(manifest.KeyFingerprint)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to decrypt the backup")
+			return "", nil, err
+		}
+
+		decrypted := filepath.Join(workDir, "decrypted")
+		if err := decryptPath(localPath, decrypted, identity); err != nil {
+			log.Warn().Err(err).Msg("Failed to decrypt the backup")
+			return "", nil, err
+		}
+		localPath = decrypted
+	}
+
+	if compression := config.BackupCompressionOf(task.Data.BackupPath); compression != config.BackupCompressionNone {
+		decompressed := filepath.Join(workDir, "decompressed")
+		if err := decompressFile(localPath, decompressed, compression); err != nil {
+			log.Warn().Err(err).
+				Str("Compression", compression).
+				Msg("Failed to decompress the backup")
+			return "", nil, err
+		}
+		localPath = decompressed
+	}
+
+	return localPath, release, nil
+}
+
+// restoreBackup restores the fetched backup into the database dbName
+func (h *DbTaskHandler) restoreBackup(task *DbTask, dbName string, localPath string) error {
 	args := []string{
 		"-h", h.DbConfig.Host(nil),
 		"-p", fmt.Sprintf("%d", h.DbConfig.Port),
 		"-U", h.DbConfig.User,
-		"-d", task.DbName,
-		"-f", task.Data.BackupPath,
+		"-d", dbName,
 	}
 
-	cmd := exec.Command("psql", args...)
+	plain := backupFormat(task) == config.BackupFormatPlain
+	var cmd *exec.Cmd
+	if plain {
+		args = append(args, "-f", localPath)
+		cmd = exec.CommandContext(task.Context(), "psql", args...)
+	} else {
+		if task.IsClone() {
+			// The objects of the clone are owned by its own owner instead of the source owner
+			args = append(args, "--no-owner", "--no-privileges", "--role="+task.Data.Owner)
+		}
+		args = append(args, "-j", fmt.Sprint(max(h.DbConfig.BackupJobs, 1)), localPath)
+		cmd = exec.CommandContext(task.Context(), "pg_restore", args...)
+	}
 	cmd.Dir = h.DbConfig.BackupRootPath
 	cmd.Stdin = strings.NewReader(h.DbConfig.Password + "\n")
 	var stdErr bytes.Buffer
@@ -71,12 +302,106 @@ func (h *DbTaskHandler) RestoreDb(task *DbTask) (err error) {
 	if err := cmd.Run(); err != nil {
 		log.Error().Err(err).
 			Strs("Args", args).
-			Str("DbName", task.DbName).
+			Str("DbName", dbName).
 			Str("BackupPath", task.Data.BackupPath).
 			Str("StdErr", stdErr.String()).
 			Msg("Failed to restore database")
 		return err
 	}
 
+	if plain && task.IsClone() {
+		// The plain dump always restores the owners of the source
+		return h.reassignClonedObjects(task, dbName)
+	}
+	return nil
+}
+
+// reassignClonedObjects gives the objects restored from the plain dump of the source database
+// to the owner of the clone.
+func (h *DbTaskHandler) reassignClonedObjects(task *DbTask, dbName string) error {
+	connCtx := task.Context()
+	log := log.With().
+		Str("DbName", dbName).
+		Str("SourceDb", task.Data.SourceDb).
+		Str("Owner", task.Data.Owner).
+		Logger()
+
+	source, err := h.DbApi.GetDbByName(task.Data.SourceDb, nil)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get the owner of the source database")
+		return err
+	}
+	if source.Owner == task.Data.Owner {
+		return nil
+	}
+
+	conn, err := pgx.Connect(connCtx, h.DbConfig.Url(dbName, nil))
+	if err != nil {
+		return err
+	}
+	defer conn.Close(connCtx)
+
+	err = pgx.BeginFunc(connCtx, conn, func(tx pgx.Tx) error {
+		sourceOwner := pgx.Identifier{source.Owner}.Sanitize()
+
+		// REASSIGN OWNED also changes the owner of the shared objects, e.g. the source database,
+		// they are given back to the source owner in the same transaction.
+		rows, err := tx.Query(connCtx,
+			`SELECT 'DATABASE ' || quote_ident(datname) FROM pg_database WHERE datdba = $1::regrole
+			UNION ALL
+			SELECT 'TABLESPACE ' || quote_ident(spcname) FROM pg_tablespace WHERE spcowner = $1::regrole`,
+			sourceOwner)
+		if err != nil {
+			return err
+		}
+		sharedObjects, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(connCtx, fmt.Sprintf("REASSIGN OWNED BY %s TO %s",
+			sourceOwner, pgx.Identifier{task.Data.Owner}.Sanitize())); err != nil {
+			return err
+		}
+		for _, object := range sharedObjects {
+			if _, err := tx.Exec(connCtx, fmt.Sprintf("ALTER %s OWNER TO %s", object, sourceOwner)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to reassign the restored objects to the owner of the clone")
+		return err
+	}
 	return nil
 }
+
+// verifyBackup checks the fetched backup against its manifest.
+// The backup without a manifest is incomplete, or created before the manifest was introduced,
+// it is only restored if BackupConfig.AllowLegacyBackups is enabled.
+func (h *DbTaskHandler) verifyBackup(task *DbTask, localPath string) (*config.BackupManifest, error) {
+	manifest, err := storage.LoadManifest(task.Context(), h.Storage, task.Data.BackupPath)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotExist) {
+			return nil, err
+		}
+		if !h.BackupConfig.AllowLegacyBackups {
+			return nil, errors.New("the backup has no manifest, it is incomplete or too old to restore")
+		}
+		log.Warn().Str("DbName", task.DbName).
+			Str("BackupPath", task.Data.BackupPath).
+			Msg("The backup has no manifest, skip the integrity check")
+	}
+
+	if _, err := h.DbConfig.ValidateBackupPath(task.Data.BackupPath, task.SourceDbName(), manifest); err != nil {
+		return nil, err
+	}
+
+	if manifest != nil {
+		if err := manifest.Verify(localPath); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
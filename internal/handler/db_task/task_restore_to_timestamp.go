@@ -0,0 +1,145 @@
+package db_task
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// RestoreToTimestamp prepares a point-in-time recovery in a new data directory,
+// the recovery runs when a new instance is started from the data directory.
+func (h *DbTaskHandler) RestoreToTimestamp(task *DbTask) (err error) {
+	pitr := &h.BackupConfig.Pitr
+	log := log.With().
+		Str("Action", string(task.Action)).
+		Str("DataDir", task.Data.DataDir).
+		Logger()
+
+	task.Status = db.DbTaskStatusRunning
+	h.DbApi.UpdateTaskStatus(task.DbTask, nil)
+	defer func() { setFinalTaskStatus(h.DbApi, task, err) }()
+
+	if !pitr.Enabled {
+		return errors.New("point-in-time recovery is disabled")
+	}
+
+	if task.Data.TargetTime == nil {
+		return errors.New("no target time")
+	}
+	if task.Data.DataDir, err = pitr.RestoreDataDir(task.Data.DataDir); err != nil {
+		log.Warn().Err(err).Msg("Can not restore to the timestamp")
+		return err
+	}
+	target := task.Data.TargetTime.UTC()
+
+	window := RecoveryWindow(h.DbApi, pitr)
+	start, end := window.StartTime.AsTime(), window.EndTime.AsTime()
+	if !window.Available || target.Before(start) || target.After(end) {
+		err := fmt.Errorf("target time is out of the recovery window [%s, %s]",
+			start.Format(time.RFC3339), end.Format(time.RFC3339))
+		log.Warn().Err(err).Time("TargetTime", target).Msg("Can not restore to the timestamp")
+		return err
+	}
+
+	var backup db.BaseBackup
+	err = h.DbApi.Query(func(q *db.Queries) error {
+		var err error
+		backup, err = q.GetLatestBaseBackupBefore(h.DbApi.ConnCtx, pgtype.Timestamp{Time: target, Valid: true})
+		return err
+	})
+	if err != nil {
+		log.Warn().Err(err).Time("TargetTime", target).Msg("Failed to find the base backup")
+		return err
+	}
+	task.Data.BackupPath = backup.Path
+	h.DbApi.UpdateTaskData(task.DbTask, nil)
+
+	if entries, err := os.ReadDir(task.Data.DataDir); err == nil && len(entries) > 0 {
+		err := errors.New("data dir is not empty")
+		log.Warn().Err(err).Msg("Can not restore to the timestamp")
+		return err
+	}
+
+	baseDir := filepath.Join(pitr.BaseBackupRootPath, filepath.FromSlash(backup.Path))
+	if err := copyDir(baseDir, task.Data.DataDir); err != nil {
+		log.Warn().Err(err).Str("BackupPath", backup.Path).Msg("Failed to copy the base backup")
+		return err
+	}
+
+	if err := h.writeRecoveryConfig(task.Data.DataDir, target); err != nil {
+		log.Warn().Err(err).Msg("Failed to write the recovery config")
+		return err
+	}
+
+	log.Log().Str("BackupPath", backup.Path).
+		Time("TargetTime", target).
+		Msg("Point-in-time recovery is prepared, start a new instance with the data dir to recover")
+	return nil
+}
+
+func (h *DbTaskHandler) writeRecoveryConfig(dataDir string, target time.Time) error {
+	if err := os.WriteFile(filepath.Join(dataDir, "recovery.signal"), nil, 0600); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dataDir, "postgresql.auto.conf"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	archive := h.BackupConfig.Pitr.WalArchivePath
+	_, err = fmt.Fprintf(f, "\n# Added by pg-helper for the point-in-time recovery\n"+
+		"restore_command = 'cp %s/%%f %%p'\n"+
+		"recovery_target_time = '%s'\n"+
+		"recovery_target_action = 'promote'\n",
+		archive, target.Format("2006-01-02 15:04:05.999999Z07:00"))
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// copyDir copies the base backup to the new data dir,
+// postgres requires the data dir is only accessible by its owner.
+func copyDir(src string, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0700)
+		}
+
+		in, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		if _, err := io.Copy(out, in); err != nil {
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,68 @@
+package db_task
+
+import (
+	"fmt"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func (h *DbTaskHandler) Rollback(task *DbTask) error {
+	return h.DbApi.Query(func(q *db.Queries) error {
+		return h.rollback(task, q)
+	})
+}
+
+func (h *DbTaskHandler) rollback(task *DbTask, q *db.Queries) (err error) {
+	log.Debug().Str("DbName", task.DbName).
+		Msg("Start to rollback database")
+
+	task.Status = db.DbTaskStatusRunning
+	h.DbApi.UpdateTaskStatus(task.DbTask, q)
+	defer func() { setFinalTaskStatus(h.DbApi, task, err) }()
+
+	db_, err := h.DbApi.GetDb(task.DbID, q)
+	if err != nil {
+		log.Error().Err(err).
+			Str("DbName", task.DbName).
+			Msg("Can not rollback database due to db error")
+		return logger.NewAlreadyLoggedError(err, zerolog.ErrorLevel)
+	}
+
+	if !db_.CanRollback() {
+		err := fmt.Errorf("db stage is not idle")
+		log.Error().Err(err).
+			Str("DbName", task.DbName).
+			Str("Stage", db_.Stage.String()).
+			Str("Status", db_.Status.String()).
+			Msg("Failed to rollback database")
+		return logger.NewAlreadyLoggedError(err, zerolog.ErrorLevel)
+	}
+
+	// The connections are blocked by the cutover of a logical migration
+	if _, err := q.Conn().Exec(task.Context(),
+		fmt.Sprintf("ALTER DATABASE %s CONNECTION LIMIT -1", pgx.Identifier{task.DbName}.Sanitize())); err != nil {
+		log.Error().Err(err).
+			Str("DbName", task.DbName).
+			Msg("Failed to unblock the connections of the database")
+		return logger.NewAlreadyLoggedError(err, zerolog.ErrorLevel)
+	}
+
+	// The data is untouched while the database is idle,
+	// so it is safe to serve it again.
+	db_.Stage = proto.DbStage_ReadyToUse
+	db_.Status = proto.DbStatus_Done
+	db_.MigrateTo = ""
+	db_.ErrorMsg = ""
+	db_.ExpiredAt = pgtype.Timestamp{}
+	h.DbApi.UpdateDbStatus(db_, q)
+
+	log.Log().Str("DbName", task.DbName).
+		Msg("Database rollback completed")
+	return nil
+}
@@ -0,0 +1,274 @@
+package db_task
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// VerifyBackup restores the backup into a scratch database and runs the sanity checks there,
+// the database that the backup belongs to is not touched.
+func (h *DbTaskHandler) VerifyBackup(task *DbTask) (err error) {
+	log := log.With().
+		Str("DbName", task.DbName).
+		Str("Action", string(task.Action)).
+		Logger()
+
+	task.Status = db.DbTaskStatusRunning
+	h.DbApi.UpdateTaskStatus(task.DbTask, nil)
+	defer func() { setFinalTaskStatus(h.DbApi, task, err) }()
+	defer func() { h.notifyBackupVerification(task, err) }()
+
+	if task.Data.BackupPath == "" {
+		if task.Data.BackupPath, err = h.latestBackup(task.DbName); err != nil {
+			log.Warn().Err(err).Msg("Failed to find the backup to verify")
+			return err
+		}
+	}
+	log = log.With().Str("BackupPath", task.Data.BackupPath).Logger()
+
+	localPath, release, err := h.fetchBackup(task)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	scratchDb := fmt.Sprintf("pg_helper_verify_%s", task.ID.String()[:8])
+
+	// The assertions are provided by the api users,
+	// they run as a role that can only read the tables of the scratch database.
+	// The role is dropped after the scratch database, which holds its privileges.
+	verifier, err := h.createVerifyRole(task.Context(), scratchDb)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("Role", scratchDb).
+			Msg("Failed to create the role to run the assertions")
+		return err
+	}
+	defer h.dropVerifyRole(verifier.name)
+
+	if err := h.createScratchDb(task.Context(), scratchDb); err != nil {
+		log.Warn().Err(err).
+			Str("ScratchDb", scratchDb).
+			Msg("Failed to create the scratch database")
+		return err
+	}
+	defer h.dropScratchDb(scratchDb)
+
+	if err := h.restoreBackup(task, scratchDb, localPath); err != nil {
+		return err
+	}
+
+	if err := h.checkScratchDb(task, scratchDb, verifier); err != nil {
+		log.Warn().Err(err).
+			Int64("TableCount", task.Data.TableCount).
+			Strs("FailedAssertions", task.Data.FailedAssertions).
+			Msg("Backup verification failed")
+		return err
+	}
+
+	log.Log().Int64("TableCount", task.Data.TableCount).
+		Msg("Backup verification passed")
+	return nil
+}
+
+func (h *DbTaskHandler) latestBackup(dbName string) (string, error) {
+	backups, err := h.listBackups(dbName)
+	if err != nil {
+		return "", err
+	}
+	if len(backups) == 0 {
+		return "", errors.New("no backup found")
+	}
+
+	latest := backups[0]
+	for _, backup := range backups[1:] {
+		if backup.CreatedAt.After(latest.CreatedAt) {
+			latest = backup
+		}
+	}
+	return latest.Path, nil
+}
+
+func (h *DbTaskHandler) createScratchDb(connCtx context.Context, name string) error {
+	return h.DbApi.Query(func(q *db.Queries) error {
+		_, err := q.Conn().Exec(connCtx,
+			fmt.Sprintf("CREATE DATABASE %s", pgx.Identifier{name}.Sanitize()))
+		return err
+	})
+}
+
+func (h *DbTaskHandler) dropScratchDb(name string) {
+	err := h.DbApi.Query(func(q *db.Queries) error {
+		_, err := q.Conn().Exec(h.DbApi.ConnCtx,
+			fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", pgx.Identifier{name}.Sanitize()))
+		return err
+	})
+	if err != nil {
+		log.Warn().Err(err).
+			Str("ScratchDb", name).
+			Msg("Failed to drop the scratch database")
+	}
+}
+
+type verifyRole struct {
+	name     string
+	password string
+}
+
+// createVerifyRole creates a login role without any privilege,
+// it can not read the server files or run programs as the superuser can.
+func (h *DbTaskHandler) createVerifyRole(connCtx context.Context, name string) (*verifyRole, error) {
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	role := &verifyRole{name: name, password: hex.EncodeToString(secret)}
+
+	err := h.DbApi.Query(func(q *db.Queries) error {
+		// The password is hex encoded, it is safe to quote it as a literal
+		_, err := q.Conn().Exec(connCtx,
+			fmt.Sprintf("CREATE ROLE %s LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE NOINHERIT PASSWORD '%s'",
+				pgx.Identifier{role.name}.Sanitize(), role.password))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (h *DbTaskHandler) dropVerifyRole(name string) {
+	err := h.DbApi.Query(func(q *db.Queries) error {
+		_, err := q.Conn().Exec(h.DbApi.ConnCtx,
+			fmt.Sprintf("DROP ROLE IF EXISTS %s", pgx.Identifier{name}.Sanitize()))
+		return err
+	})
+	if err != nil {
+		log.Warn().Err(err).
+			Str("Role", name).
+			Msg("Failed to drop the role to run the assertions")
+	}
+}
+
+// grantVerifyRole allows the role to read the tables of the user schemas in the scratch database
+func (h *DbTaskHandler) grantVerifyRole(connCtx context.Context, conn *pgx.Conn, role string) error {
+	rows, err := conn.Query(connCtx,
+		`SELECT nspname FROM pg_namespace
+		WHERE nspname NOT LIKE 'pg\_%' AND nspname <> 'information_schema'`)
+	if err != nil {
+		return err
+	}
+	schemas, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+
+	roleId := pgx.Identifier{role}.Sanitize()
+	for _, schema := range schemas {
+		schemaId := pgx.Identifier{schema}.Sanitize()
+		if _, err := conn.Exec(connCtx,
+			fmt.Sprintf("GRANT USAGE ON SCHEMA %s TO %s", schemaId, roleId)); err != nil {
+			return err
+		}
+		if _, err := conn.Exec(connCtx,
+			fmt.Sprintf("GRANT SELECT ON ALL TABLES IN SCHEMA %s TO %s", schemaId, roleId)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkScratchDb counts the tables and runs the assertions in the scratch database
+func (h *DbTaskHandler) checkScratchDb(task *DbTask, scratchDb string, verifier *verifyRole) error {
+	connCtx := task.Context()
+	conn, err := pgx.Connect(connCtx, h.DbConfig.Url(scratchDb, nil))
+	if err != nil {
+		return err
+	}
+	defer conn.Close(connCtx)
+
+	// The table count is only recorded, the backup of an empty database is valid too.
+	// An assertion can be used to require the tables.
+	task.Data.TableCount, err = db.New(conn).CountDbTables(connCtx)
+	if err != nil {
+		return err
+	}
+
+	task.Data.FailedAssertions = nil
+	if len(task.Data.VerifyAssertions) == 0 {
+		return nil
+	}
+
+	if err := h.grantVerifyRole(connCtx, conn, verifier.name); err != nil {
+		return err
+	}
+	verifierConn, err := pgx.Connect(connCtx,
+		h.DbConfig.UrlAs(verifier.name, verifier.password, scratchDb, nil))
+	if err != nil {
+		return err
+	}
+	defer verifierConn.Close(connCtx)
+
+	for _, assertion := range task.Data.VerifyAssertions {
+		if !h.assert(connCtx, verifierConn, assertion) {
+			task.Data.FailedAssertions = append(task.Data.FailedAssertions, assertion)
+		}
+	}
+	if len(task.Data.FailedAssertions) > 0 {
+		return fmt.Errorf("%d of %d assertions failed",
+			len(task.Data.FailedAssertions), len(task.Data.VerifyAssertions))
+	}
+	return nil
+}
+
+// assert runs the assertion in a read only transaction,
+// the assertion passes only if it returns true.
+func (h *DbTaskHandler) assert(connCtx context.Context, conn *pgx.Conn, assertion string) bool {
+	tx, err := conn.BeginTx(connCtx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return false
+	}
+	defer tx.Rollback(connCtx)
+
+	var passed bool
+	if err := tx.QueryRow(connCtx, assertion).Scan(&passed); err != nil {
+		log.Debug().Err(err).
+			Str("Assertion", assertion).
+			Msg("Failed to run the assertion")
+		return false
+	}
+	return passed
+}
+
+func (h *DbTaskHandler) notifyBackupVerification(task *DbTask, err error) {
+	h.DbApi.UpdateTaskData(task.DbTask, nil)
+
+	verification := &proto.BackupVerification{
+		Name:             task.DbName,
+		InstanceName:     h.DbConfig.InstanceName,
+		JobId:            task.JobID().String(),
+		BackupPath:       task.Data.BackupPath,
+		Passed:           err == nil,
+		TableCount:       task.Data.TableCount,
+		FailedAssertions: task.Data.FailedAssertions,
+		VerifiedAt:       timestamppb.New(time.Now()),
+	}
+	if err != nil {
+		verification.ErrorMsg = err.Error()
+	}
+	h.backupNotifier.Send(verification)
+
+	if verification.BackupPath != "" {
+		h.DbApi.SetBackupVerified(verification.BackupPath, verification.Passed, nil)
+	}
+}
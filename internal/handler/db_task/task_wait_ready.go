@@ -24,7 +24,8 @@ func (h *DbTaskHandler) WaitReadyDb(task *DbTask) (err error) {
 
 	if db_.Status == proto.DbStatus_Done &&
 		(db_.Stage == proto.DbStage_CreateDatabase ||
-			db_.Stage == proto.DbStage_RestoreDatabase) {
+			db_.Stage == proto.DbStage_RestoreDatabase ||
+			db_.Stage == proto.DbStage_ReplicateDatabase) {
 		db_.Stage = proto.DbStage_ReadyToUse
 		h.DbApi.UpdateDbStatus(db_, nil)
 		return nil
@@ -214,8 +214,15 @@ func (s *GrpcAgentServer) loadRegisterAgent() (*proto.RegisterInstance, error) {
 		return nil, err
 	} else {
 		registerAgent.Databases = s.DbApi.ToProtoDatabases(dbs)
-		return registerAgent, nil
 	}
+
+	if backups, err := s.DbApi.ListBackups(nil); err != nil {
+		log.Error().Err(err).Msg("Failed to get backups when load register agent")
+		return nil, err
+	} else {
+		registerAgent.Backups = s.DbApi.ToProtoBackups(backups)
+	}
+	return registerAgent, nil
 }
 
 type registerAgentLoader struct {
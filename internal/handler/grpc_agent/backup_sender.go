@@ -0,0 +1,41 @@
+package grpc_agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/a-light-win/pg-helper/internal/constants"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/server"
+)
+
+type BackupSender struct {
+	grpcClient proto.DbJobSvcClient
+	connCtx    context.Context
+}
+
+func (s *BackupSender) Handle(msg server.NamedElement) error {
+	var err error
+	switch msg := msg.(type) {
+	case *proto.Backup:
+		_, err = s.grpcClient.NotifyBackup(s.connCtx, msg)
+	case *proto.BackupVerification:
+		_, err = s.grpcClient.NotifyBackupVerification(s.connCtx, msg)
+	case *proto.RecoveryWindow:
+		_, err = s.grpcClient.NotifyRecoveryWindow(s.connCtx, msg)
+	default:
+		err = fmt.Errorf("invalid backup message type %T", msg)
+	}
+	return err
+}
+
+func (s *BackupSender) Init(setter server.GlobalSetter) error {
+	return nil
+}
+
+func (s *BackupSender) PostInit(getter server.GlobalGetter) error {
+	s.grpcClient = getter.Get(constants.AgentKeyGrpcClient).(proto.DbJobSvcClient)
+	s.connCtx = getter.Get(constants.AgentKeyConnCtx).(context.Context)
+
+	return nil
+}
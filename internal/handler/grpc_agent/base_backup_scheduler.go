@@ -0,0 +1,113 @@
+package grpc_agent
+
+import (
+	"context"
+	"time"
+
+	config "github.com/a-light-win/pg-helper/internal/config/agent"
+	"github.com/a-light-win/pg-helper/internal/constants"
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/handler/db_task"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/server"
+	"github.com/rs/zerolog/log"
+)
+
+// BaseBackupScheduler takes a base backup of the instance
+// when the latest one is older than PitrConfig.BaseBackupInterval,
+// and reports the recovery window to the manager as the WAL is archived.
+type BaseBackupScheduler struct {
+	Config  *config.BackupConfig
+	QuitCtx context.Context
+
+	handler        *GrpcAgentHandler
+	backupNotifier server.Producer
+
+	exited chan struct{}
+}
+
+func NewBaseBackupScheduler(backupConfig *config.BackupConfig, quitCtx context.Context) *BaseBackupScheduler {
+	return &BaseBackupScheduler{
+		Config:  backupConfig,
+		QuitCtx: quitCtx,
+		exited:  make(chan struct{}),
+	}
+}
+
+func (s *BaseBackupScheduler) Init(setter server.GlobalSetter) error {
+	return nil
+}
+
+func (s *BaseBackupScheduler) PostInit(getter server.GlobalGetter) error {
+	dbApi := getter.Get(constants.AgentKeyDbApi).(*db.DbApi)
+	grpcClient := getter.Get(constants.AgentKeyGrpcClient).(proto.DbJobSvcClient)
+	jobProducer := getter.Get(constants.AgentKeyJobProducer).(server.Producer)
+
+	s.handler = NewGrpcAgentHandler(dbApi, grpcClient, jobProducer, s.QuitCtx)
+	s.backupNotifier = getter.Get(constants.AgentKeyNotifyBackupProducer).(server.Producer)
+	return nil
+}
+
+func (s *BaseBackupScheduler) Run() {
+	defer func() {
+		s.exited <- struct{}{}
+	}()
+
+	pitr := &s.Config.Pitr
+	if !pitr.Enabled {
+		log.Log().Msg("Base backup scheduler is disabled")
+		return
+	}
+
+	log.Log().
+		Dur("Interval", pitr.BaseBackupInterval).
+		Msg("Base backup scheduler is running")
+
+	for {
+		select {
+		case <-s.QuitCtx.Done():
+			return
+		case <-time.After(s.Config.CheckInterval):
+			// The end of the window moves as the WAL is archived,
+			// it is reported on every check rather than only after a base backup.
+			s.backupNotifier.Send(db_task.RecoveryWindow(s.handler.DbApi, pitr))
+			if pitr.BaseBackupInterval > 0 {
+				s.schedule(time.Now().UTC())
+			}
+		}
+	}
+}
+
+func (s *BaseBackupScheduler) Shutdown(ctx context.Context) {
+	log.Log().Msg("Base backup scheduler is shutting down")
+
+	<-s.exited
+
+	log.Log().Msg("Base backup scheduler is down")
+}
+
+func (s *BaseBackupScheduler) schedule(now time.Time) {
+	dbApi := s.handler.DbApi
+
+	var backups []db.BaseBackup
+	err := dbApi.Query(func(q *db.Queries) error {
+		var err error
+		backups, err = q.ListBaseBackups(dbApi.ConnCtx)
+		return err
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list base backups")
+		return
+	}
+
+	if len(backups) > 0 && now.Sub(backups[0].FinishedAt.Time) < s.Config.Pitr.BaseBackupInterval {
+		return
+	}
+
+	log.Info().Msg("Schedule the base backup")
+
+	request := &BaseBackupRequest{
+		BaseBackupJob: &proto.BaseBackupJob{Reason: "Scheduled base backup"},
+	}
+	request.Process(s.handler)
+}
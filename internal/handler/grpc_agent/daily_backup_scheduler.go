@@ -0,0 +1,107 @@
+package grpc_agent
+
+import (
+	"context"
+	"time"
+
+	config "github.com/a-light-win/pg-helper/internal/config/agent"
+	"github.com/a-light-win/pg-helper/internal/constants"
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/server"
+	"github.com/rs/zerolog/log"
+)
+
+// DailyBackupScheduler creates the daily backup jobs
+// for the ready to use databases in the backup window.
+type DailyBackupScheduler struct {
+	Config  *config.BackupConfig
+	QuitCtx context.Context
+
+	handler *GrpcAgentHandler
+
+	exited chan struct{}
+}
+
+func NewDailyBackupScheduler(backupConfig *config.BackupConfig, quitCtx context.Context) *DailyBackupScheduler {
+	return &DailyBackupScheduler{
+		Config:  backupConfig,
+		QuitCtx: quitCtx,
+		exited:  make(chan struct{}),
+	}
+}
+
+func (s *DailyBackupScheduler) Init(setter server.GlobalSetter) error {
+	return nil
+}
+
+func (s *DailyBackupScheduler) PostInit(getter server.GlobalGetter) error {
+	dbApi := getter.Get(constants.AgentKeyDbApi).(*db.DbApi)
+	grpcClient := getter.Get(constants.AgentKeyGrpcClient).(proto.DbJobSvcClient)
+	jobProducer := getter.Get(constants.AgentKeyJobProducer).(server.Producer)
+
+	s.handler = NewGrpcAgentHandler(dbApi, grpcClient, jobProducer, s.QuitCtx)
+	return nil
+}
+
+func (s *DailyBackupScheduler) Run() {
+	defer func() {
+		s.exited <- struct{}{}
+	}()
+
+	if !s.Config.DailyEnabled {
+		log.Log().Msg("Daily backup scheduler is disabled")
+		return
+	}
+
+	log.Log().
+		Str("WindowStart", s.Config.WindowStart).
+		Str("WindowEnd", s.Config.WindowEnd).
+		Msg("Daily backup scheduler is running")
+
+	for {
+		select {
+		case <-s.QuitCtx.Done():
+			return
+		case <-time.After(s.Config.CheckInterval):
+			s.schedule(time.Now())
+		}
+	}
+}
+
+func (s *DailyBackupScheduler) Shutdown(ctx context.Context) {
+	log.Log().Msg("Daily backup scheduler is shutting down")
+
+	<-s.exited
+
+	log.Log().Msg("Daily backup scheduler is down")
+}
+
+func (s *DailyBackupScheduler) schedule(now time.Time) {
+	windowStart, ok := s.Config.CurrentWindow(now)
+	if !ok {
+		return
+	}
+
+	dbs, err := s.handler.DbApi.ListDbsToDailyBackup(windowStart, nil)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list databases to daily backup")
+		return
+	}
+
+	for i := range dbs {
+		if !s.Config.ShouldDailyBackup(dbs[i].Name) {
+			continue
+		}
+
+		log.Info().Str("DbName", dbs[i].Name).
+			Msg("Schedule the daily backup")
+
+		request := &DailyBackupRequest{
+			Name:   dbs[i].Name,
+			Reason: "Daily backup",
+			Prune:  s.Config.RetentionEnabled(),
+		}
+		request.Process(s.handler)
+	}
+}
@@ -37,6 +37,33 @@ func (h *GrpcAgentHandler) handle(task *proto.DbJob) error {
 	case *proto.DbJob_MigrateOutDatabase:
 		request := NewMigrateOutDatabaseRequest(task)
 		return request.Process(h)
+	case *proto.DbJob_DropDatabase:
+		request := NewDropDatabaseRequest(task)
+		return request.Process(h)
+	case *proto.DbJob_RollbackDatabase:
+		request := NewRollbackDatabaseRequest(task)
+		return request.Process(h)
+	case *proto.DbJob_VerifyBackup:
+		request := NewVerifyBackupRequest(task)
+		return request.Process(h)
+	case *proto.DbJob_BaseBackup:
+		request := NewBaseBackupRequest(task)
+		return request.Process(h)
+	case *proto.DbJob_RestoreToTimestamp:
+		request := NewRestoreToTimestampRequest(task)
+		return request.Process(h)
+	case *proto.DbJob_BackupDatabase:
+		request := NewBackupDatabaseRequest(task)
+		return request.Process(h)
+	case *proto.DbJob_RestoreDatabase:
+		request := NewRestoreDatabaseRequest(task)
+		return request.Process(h)
+	case *proto.DbJob_CloneDatabase:
+		request := NewCloneDatabaseRequest(task)
+		return request.Process(h)
+	case *proto.DbJob_CancelJob:
+		request := NewCancelJobRequest(task)
+		return request.Process(h)
 	}
 	return nil
 }
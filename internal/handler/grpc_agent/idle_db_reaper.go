@@ -0,0 +1,211 @@
+package grpc_agent
+
+import (
+	"context"
+	"time"
+
+	config "github.com/a-light-win/pg-helper/internal/config/agent"
+	"github.com/a-light-win/pg-helper/internal/constants"
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/server"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The delay before checking a drop again, it is doubled on every failure
+const dropRetryDelay = time.Minute
+
+// IdleDbReaper drops the idle databases once their expired_at passes,
+// the expired ready to use databases (e.g. the clones) are made idle first.
+type IdleDbReaper struct {
+	DbConfig *config.DbConfig
+	QuitCtx  context.Context
+
+	handler *GrpcAgentHandler
+
+	// The drops to check again, only accessed in Run
+	retries map[string]*dropRetry
+
+	exited chan struct{}
+}
+
+type dropRetry struct {
+	RetryTimes int
+	NextAt     time.Time
+}
+
+func NewIdleDbReaper(dbConfig *config.DbConfig, quitCtx context.Context) *IdleDbReaper {
+	return &IdleDbReaper{
+		DbConfig: dbConfig,
+		QuitCtx:  quitCtx,
+		retries:  make(map[string]*dropRetry),
+		exited:   make(chan struct{}),
+	}
+}
+
+func (r *IdleDbReaper) Init(setter server.GlobalSetter) error {
+	return nil
+}
+
+func (r *IdleDbReaper) PostInit(getter server.GlobalGetter) error {
+	dbApi := getter.Get(constants.AgentKeyDbApi).(*db.DbApi)
+	grpcClient := getter.Get(constants.AgentKeyGrpcClient).(proto.DbJobSvcClient)
+	jobProducer := getter.Get(constants.AgentKeyJobProducer).(server.Producer)
+
+	r.handler = NewGrpcAgentHandler(dbApi, grpcClient, jobProducer, r.QuitCtx)
+	return nil
+}
+
+func (r *IdleDbReaper) Run() {
+	defer func() {
+		r.exited <- struct{}{}
+	}()
+
+	if r.DbConfig.IdleDbCheckInterval <= 0 {
+		log.Log().Msg("Idle db reaper is disabled")
+		return
+	}
+
+	log.Log().Msg("Idle db reaper is running")
+
+	for {
+		select {
+		case <-r.QuitCtx.Done():
+			return
+		case <-time.After(r.nextReapDelay()):
+			r.reap()
+		}
+	}
+}
+
+func (r *IdleDbReaper) Shutdown(ctx context.Context) {
+	log.Log().Msg("Idle db reaper is shutting down")
+
+	<-r.exited
+
+	log.Log().Msg("Idle db reaper is down")
+}
+
+func (r *IdleDbReaper) reap() {
+	r.idleExpiredReadyDbs()
+
+	dbs, err := r.handler.DbApi.ListExpiredIdleDbs(nil)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list expired idle databases")
+		return
+	}
+
+	for i := range dbs {
+		log.Info().Str("DbName", dbs[i].Name).
+			Time("ExpiredAt", dbs[i].ExpiredAt.Time).
+			Msg("Idle database is expired, drop it")
+
+		r.drop(&dbs[i], "")
+		// Check the result of the drop soon, instead of waiting for the next round
+		r.retries[dbs[i].Name] = &dropRetry{NextAt: time.Now().Add(dropRetryDelay)}
+	}
+
+	r.retryFailedDrops()
+}
+
+// retryFailedDrops resumes the failed drops with an exponential backoff
+func (r *IdleDbReaper) retryFailedDrops() {
+	dbs, err := r.handler.DbApi.ListExpiredDropFailedDbs(nil)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list the databases failed to drop")
+		return
+	}
+
+	now := time.Now()
+	failed := make(map[string]bool, len(dbs))
+	for i := range dbs {
+		failed[dbs[i].Name] = true
+
+		retry, ok := r.retries[dbs[i].Name]
+		if !ok {
+			retry = &dropRetry{}
+			r.retries[dbs[i].Name] = retry
+		}
+		if retry.NextAt.After(now) {
+			continue
+		}
+
+		retry.RetryTimes++
+		retry.NextAt = now.Add(r.retryDelay(retry.RetryTimes))
+		log.Warn().Str("DbName", dbs[i].Name).
+			Int("RetryTimes", retry.RetryTimes).
+			Time("NextRetryAt", retry.NextAt).
+			Msg("Drop idle database failed, retry it")
+
+		// Resume the failed drop job from the failed task
+		r.drop(&dbs[i], dbs[i].LastJobID.String())
+	}
+
+	// The database is dropped, or the drop is still running
+	for name, retry := range r.retries {
+		if !failed[name] && !retry.NextAt.After(now) {
+			delete(r.retries, name)
+		}
+	}
+}
+
+func (r *IdleDbReaper) drop(database *db.Db, jobId string) {
+	job := &proto.DbJob{
+		JobId: jobId,
+		Job: &proto.DbJob_DropDatabase{
+			DropDatabase: &proto.DropDatabaseJob{
+				Name:      database.Name,
+				Reason:    "Idle database is expired",
+				DropOwner: r.DbConfig.DropIdleDbOwner,
+			},
+		},
+	}
+	if err := r.handler.handle(job); err != nil {
+		log.Warn().Err(err).Str("DbName", database.Name).Msg("Failed to drop the idle database")
+	}
+}
+
+func (r *IdleDbReaper) retryDelay(retryTimes int) time.Duration {
+	delay := dropRetryDelay
+	for i := 1; i < retryTimes && delay < r.DbConfig.IdleDbCheckInterval; i++ {
+		delay *= 2
+	}
+	return min(delay, r.DbConfig.IdleDbCheckInterval)
+}
+
+// nextReapDelay returns the delay to the next round,
+// it is earlier than IdleDbCheckInterval if a drop is waiting to retry.
+func (r *IdleDbReaper) nextReapDelay() time.Duration {
+	delay := r.DbConfig.IdleDbCheckInterval
+	for _, retry := range r.retries {
+		delay = min(delay, max(time.Until(retry.NextAt), 0))
+	}
+	return delay
+}
+
+func (r *IdleDbReaper) idleExpiredReadyDbs() {
+	dbs, err := r.handler.DbApi.ListExpiredReadyDbs(nil)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list expired ready databases")
+		return
+	}
+
+	for i := range dbs {
+		log.Info().Str("DbName", dbs[i].Name).
+			Time("ExpiredAt", dbs[i].ExpiredAt.Time).
+			Msg("Database is expired, make it idle")
+
+		// Keep the expired_at, so the database is dropped in the next round
+		job := &proto.DbJob{
+			Job: &proto.DbJob_MigrateOutDatabase{
+				MigrateOutDatabase: &proto.MigrateOutDatabaseJob{
+					Name:      dbs[i].Name,
+					Reason:    "Database is expired",
+					ExpiredAt: timestamppb.New(dbs[i].ExpiredAt.Time),
+				},
+			},
+		}
+		r.handler.handle(job)
+	}
+}
@@ -0,0 +1,93 @@
+package grpc_agent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/handler/db_task"
+	"github.com/a-light-win/pg-helper/internal/job"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/utils"
+	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type BackupDatabaseRequest struct {
+	*proto.BackupDatabaseJob
+	JobId uuid.UUID
+}
+
+func NewBackupDatabaseRequest(task *proto.DbJob) *BackupDatabaseRequest {
+	return &BackupDatabaseRequest{
+		BackupDatabaseJob: task.GetBackupDatabase(),
+		JobId:             utils.StringToUuid(task.JobId),
+	}
+}
+
+func (r *BackupDatabaseRequest) Process(h *GrpcAgentHandler) error {
+	return h.DbApi.QueryWithRollback(func(tx pgx.Tx) error {
+		return r.process(h, tx)
+	})
+}
+
+func (r *BackupDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
+	dbApi := h.DbApi
+	q := db.New(tx)
+
+	database, err := dbApi.GetDbByName(r.Name, q)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("Name", r.Name).
+			Msg("Backup database failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	if !database.IsReadyToUse() {
+		err := errors.New("database is not ready to use")
+		log.Warn().Err(err).
+			Str("Name", r.Name).
+			Str("Stage", database.Stage.String()).
+			Str("Status", database.Status.String()).
+			Msg("Backup database failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	// The backup does not change the db stage,
+	// so it is not recorded as the last job of the db.
+	if r.JobId == uuid.Nil {
+		r.JobId = uuid.New()
+	}
+
+	dbTaskParams := db.CreateDbTaskParams{
+		JobID:  r.JobId,
+		DbID:   database.ID,
+		DbName: database.Name,
+		Action: db.DbActionBackup,
+		Reason: r.Reason,
+		Status: db.DbTaskStatusPending,
+		Data: db.DbTaskData{
+			BackupFrom:   dbApi.DbConfig.InstanceName,
+			BackupPath:   dbApi.DbConfig.NewBackupFile(r.Name),
+			BackupFormat: dbApi.DbConfig.BackupFormat,
+		},
+	}
+	backupTask, err := dbApi.CreateDbTask(&dbTaskParams, q)
+	if err != nil {
+		return err
+	}
+
+	tx.Commit(dbApi.ConnCtx)
+
+	job_ := &job.BaseJob{
+		ID:   r.JobId,
+		Name: fmt.Sprintf("BackupDatabase-%s", r.Name),
+	}
+	job_.Tasks = append(job_.Tasks, db_task.NewDbTask(backupTask, dbApi))
+
+	h.JobProducer.Send(job_)
+	return nil
+}
@@ -0,0 +1,99 @@
+package grpc_agent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/handler/db_task"
+	"github.com/a-light-win/pg-helper/internal/job"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/utils"
+	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// The instance level tasks do not belong to a database,
+// their db_id is 0 and db_name is the instance name.
+const instanceDbID = 0
+
+type BaseBackupRequest struct {
+	*proto.BaseBackupJob
+	JobId uuid.UUID
+}
+
+func NewBaseBackupRequest(task *proto.DbJob) *BaseBackupRequest {
+	return &BaseBackupRequest{
+		BaseBackupJob: task.GetBaseBackup(),
+		JobId:         utils.StringToUuid(task.JobId),
+	}
+}
+
+func (r *BaseBackupRequest) Process(h *GrpcAgentHandler) error {
+	return h.DbApi.QueryWithRollback(func(tx pgx.Tx) error {
+		return r.process(h, tx)
+	})
+}
+
+func (r *BaseBackupRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
+	dbApi := h.DbApi
+	q := db.New(tx)
+
+	running, err := hasActiveInstanceTask(dbApi, db.DbActionBaseBackup, q)
+	if err != nil {
+		return err
+	}
+	if running {
+		err := errors.New("base backup is already running")
+		log.Warn().Err(err).Msg("Base backup failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	if r.JobId == uuid.Nil {
+		r.JobId = uuid.New()
+	}
+
+	dbTaskParams := db.CreateDbTaskParams{
+		JobID:  r.JobId,
+		DbID:   instanceDbID,
+		DbName: dbApi.DbConfig.InstanceName,
+		Action: db.DbActionBaseBackup,
+		Reason: r.Reason,
+		Status: db.DbTaskStatusPending,
+		Data: db.DbTaskData{
+			BackupPath: dbApi.DbConfig.NewBaseBackupDir(),
+		},
+	}
+	backupTask, err := dbApi.CreateDbTask(&dbTaskParams, q)
+	if err != nil {
+		return err
+	}
+
+	tx.Commit(dbApi.ConnCtx)
+
+	job_ := &job.BaseJob{
+		ID:   r.JobId,
+		Name: fmt.Sprintf("BaseBackup-%s", dbApi.DbConfig.InstanceName),
+	}
+	job_.Tasks = append(job_.Tasks, db_task.NewDbTask(backupTask, dbApi))
+
+	h.JobProducer.Send(job_)
+	return nil
+}
+
+func hasActiveInstanceTask(dbApi *db.DbApi, action db.DbAction, q *db.Queries) (bool, error) {
+	_, err := q.GetActiveDbTaskByDbID(dbApi.ConnCtx, db.GetActiveDbTaskByDbIDParams{
+		DbID:   instanceDbID,
+		Action: action,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
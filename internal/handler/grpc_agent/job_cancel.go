@@ -0,0 +1,68 @@
+package grpc_agent
+
+import (
+	"errors"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/job"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/utils"
+	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type CancelJobRequest struct {
+	*proto.CancelJob
+	// The job to cancel
+	JobId uuid.UUID
+}
+
+func NewCancelJobRequest(task *proto.DbJob) *CancelJobRequest {
+	cancelJob := task.GetCancelJob()
+	return &CancelJobRequest{
+		CancelJob: cancelJob,
+		JobId:     utils.StringToUuid(cancelJob.JobId),
+	}
+}
+
+func (r *CancelJobRequest) Process(h *GrpcAgentHandler) error {
+	log := log.With().
+		Str("JobID", r.JobId.String()).
+		Str("Name", r.Name).
+		Logger()
+
+	if r.JobId == uuid.Nil {
+		err := errors.New("job id is required")
+		log.Warn().Err(err).Msg("Cancel job failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	var tasks []db.DbTask
+	err := h.DbApi.Query(func(q *db.Queries) (err error) {
+		tasks, err = q.ListDbTasksByJobID(h.DbApi.ConnCtx, r.JobId)
+		return err
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Cancel job failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	// Only the job of the authorized database can be cancelled
+	if len(tasks) == 0 || tasks[0].DbName != r.Name {
+		err := job.ErrJobNotFound
+		log.Warn().Err(err).Msg("Cancel job failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	reason := r.Reason
+	if reason == "" {
+		reason = "Job is cancelled by request"
+	}
+	h.JobProducer.Send(&job.CancelJob{
+		ID:     r.JobId,
+		Reason: reason,
+	})
+	return nil
+}
@@ -0,0 +1,84 @@
+package grpc_agent
+
+import (
+	"errors"
+
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/utils"
+	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// CloneDatabaseRequest creates the database by the task chain of CreateDatabaseRequest,
+// with the backup taken from another database.
+type CloneDatabaseRequest struct {
+	*proto.CloneDatabaseJob
+	JobId uuid.UUID
+}
+
+func NewCloneDatabaseRequest(task *proto.DbJob) *CloneDatabaseRequest {
+	return &CloneDatabaseRequest{
+		CloneDatabaseJob: task.GetCloneDatabase(),
+		JobId:            utils.StringToUuid(task.JobId),
+	}
+}
+
+func (r *CloneDatabaseRequest) Process(h *GrpcAgentHandler) error {
+	log := log.With().
+		Str("DbName", r.Name).
+		Str("SourceName", r.SourceName).
+		Str("BackupPath", r.BackupPath).
+		Logger()
+
+	if err := r.validate(h); err != nil {
+		log.Warn().Err(err).Msg("Clone database failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	request := &CreateDatabaseRequest{
+		CreateDatabaseJob: &proto.CreateDatabaseJob{
+			Name:       r.Name,
+			Reason:     r.Reason,
+			Owner:      r.Owner,
+			Password:   r.Password,
+			BackupPath: r.BackupPath,
+		},
+		JobId:      r.JobId,
+		SourceName: r.SourceName,
+	}
+	if r.BackupPath == "" {
+		// Backup the live source database in this instance
+		request.MigrateFrom = h.DbApi.DbConfig.InstanceName
+	}
+	if r.ExpiredAt.IsValid() {
+		request.ExpiredAt = r.ExpiredAt.AsTime()
+	}
+
+	return request.Process(h)
+}
+
+func (r *CloneDatabaseRequest) validate(h *GrpcAgentHandler) error {
+	if r.Name == r.SourceName {
+		return errors.New("can not clone the database to itself")
+	}
+
+	source, err := h.DbApi.GetDbByName(r.SourceName, nil)
+	if err != nil {
+		return err
+	}
+	if r.BackupPath == "" && !source.CanBackup() {
+		return errors.New("source database can not be backed up in current stage")
+	}
+
+	database, err := h.DbApi.GetDbByName(r.Name, nil)
+	if err != nil && err != pgx.ErrNoRows {
+		return err
+	}
+	if database != nil && !database.IsNotExist() && !database.IsDropped() {
+		return errors.New("database already exists")
+	}
+	return nil
+}
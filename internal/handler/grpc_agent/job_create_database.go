@@ -2,6 +2,7 @@ package grpc_agent
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/a-light-win/pg-helper/internal/db"
 	"github.com/a-light-win/pg-helper/internal/handler/db_task"
@@ -18,6 +19,12 @@ import (
 type CreateDatabaseRequest struct {
 	*proto.CreateDatabaseJob
 	JobId uuid.UUID
+
+	// The database that the backup is taken from, it is Name if empty.
+	// It is set when cloning a database.
+	SourceName string
+	// The database is dropped after it is expired, it is set when cloning a database.
+	ExpiredAt time.Time
 }
 
 func NewCreateDatabaseRequest(job *proto.DbJob) *CreateDatabaseRequest {
@@ -43,15 +50,51 @@ func (r *CreateDatabaseRequest) validate(h *GrpcAgentHandler) error {
 		err := fmt.Errorf("the database name is Reserved")
 		return err
 	}
+	if r.MigrateFrom == "" && r.BackupPath != "" {
+		if _, err := h.DbApi.DbConfig.ValidateBackupPath(r.BackupPath, r.sourceName(), nil); err != nil {
+			return err
+		}
+	}
+	switch r.MigrateStrategy {
+	case "", proto.MigrateStrategyDump:
+	case proto.MigrateStrategyLogical:
+		if r.MigrateFrom == "" {
+			return fmt.Errorf("the logical migration requires migrate_from")
+		}
+	default:
+		return fmt.Errorf("unknown migrate strategy %s", r.MigrateStrategy)
+	}
 	return nil
 }
 
+// The database is replicated from MigrateFrom instead of restoring a backup
+func (r *CreateDatabaseRequest) isLogical() bool {
+	return r.MigrateFrom != "" && r.MigrateStrategy == proto.MigrateStrategyLogical
+}
+
+func (r *CreateDatabaseRequest) sourceName() string {
+	if r.SourceName != "" {
+		return r.SourceName
+	}
+	return r.Name
+}
+
+// backupPath returns the backup to restore from,
+// a new backup is taken when migrating from another instance.
+func (r *CreateDatabaseRequest) backupPath(h *GrpcAgentHandler) string {
+	if r.MigrateFrom == "" && r.BackupPath != "" {
+		return r.BackupPath
+	}
+	return h.DbApi.DbConfig.NewBackupFile(r.sourceName())
+}
+
 func (r *CreateDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
 	log := log.With().
 		Str("DbName", r.Name).
 		Str("Owner", r.Owner).
 		Str("Reason", r.Reason).
 		Str("MigrationFrom", r.MigrateFrom).
+		Str("MigrateStrategy", r.MigrateStrategy).
 		Logger()
 
 	dbApi := h.DbApi
@@ -70,15 +113,24 @@ func (r *CreateDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
 		if err != nil {
 			return err
 		}
+	} else if database.IsDropped() {
+		// The name of the dropped database (e.g. an expired clone) can be used again
+		database, err = dbApi.ReuseDroppedDb(database, r.Owner, q)
+		if err != nil {
+			return err
+		}
 	}
 
 	if !database.IsNotExist() {
 		// if JobId exists and equal to the LastJobId,
-		// loading the previous failed/cancelled tasks and retry
+		// resume the previous failed/cancelled tasks
 		// else new a JobId, and create new tasks.
 		if r.JobId != uuid.Nil && r.JobId == database.LastJobID {
-			// TODO: Load last failed job and retry it from the first failed task
-			return nil
+			return resumeJob(h, tx, r.JobId, fmt.Sprintf("CreateDatabase-%s", r.Name), func(task *db.DbTask) {
+				if task.Action == db.DbActionCreateUser {
+					task.Data.Password = r.Password
+				}
+			})
 		}
 	}
 
@@ -86,6 +138,14 @@ func (r *CreateDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
 		r.JobId = uuid.New()
 	}
 
+	database.LastJobID = r.JobId
+	if !r.ExpiredAt.IsZero() {
+		database.ExpiredAt.Scan(r.ExpiredAt.UTC())
+	}
+	if err := dbApi.UpdateDbStatus(database, q); err != nil {
+		return err
+	}
+
 	dbTaskParams := &db.CreateDbTaskParams{
 		JobID:  r.JobId,
 		DbID:   database.ID,
@@ -94,11 +154,20 @@ func (r *CreateDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
 		Action: db.DbActionCreateUser,
 		Status: db.DbTaskStatusPending,
 		Data: db.DbTaskData{
-			Owner:      r.Owner,
-			BackupFrom: r.MigrateFrom,
-			BackupPath: h.DbApi.DbConfig.NewBackupFile(r.Name),
+			Owner:    r.Owner,
+			SourceDb: r.SourceName,
 		},
 	}
+	if r.isLogical() {
+		dbTaskParams.Data.ReplicateFrom = r.MigrateFrom
+	} else {
+		dbTaskParams.Data.BackupFrom = r.MigrateFrom
+		dbTaskParams.Data.BackupPath = r.backupPath(h)
+	}
+	if r.MigrateFrom != "" && !r.isLogical() {
+		// The format of an existing backup is detected by its path
+		dbTaskParams.Data.BackupFormat = h.DbApi.DbConfig.BackupFormat
+	}
 
 	job_ := &job.BaseJob{
 		ID:   r.JobId,
@@ -120,7 +189,17 @@ func (r *CreateDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
 	}
 	job_.Tasks = append(job_.Tasks, db_task.NewDbTask(createDbTask, dbApi))
 
-	if r.MigrateFrom != "" {
+	if r.isLogical() {
+		for _, action := range []db.DbAction{db.DbActionReplicate, db.DbActionWaitReplication, db.DbActionCutover} {
+			dbTaskParams.Action = action
+			dbTaskParams.Data.DependsOn = []uuid.UUID{job_.Tasks[len(job_.Tasks)-1].UUID()}
+			replicateTask, err := dbApi.CreateDbTask(dbTaskParams, q)
+			if err != nil {
+				return err
+			}
+			job_.Tasks = append(job_.Tasks, db_task.NewDbTask(replicateTask, dbApi))
+		}
+	} else if r.MigrateFrom != "" {
 		dbTaskParams.Action = db.DbActionBackup
 		dbTaskParams.Data.DependsOn = []uuid.UUID{createDbTask.ID}
 		backupDbTask, err := dbApi.CreateDbTask(dbTaskParams, q)
@@ -130,7 +209,7 @@ func (r *CreateDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
 		job_.Tasks = append(job_.Tasks, db_task.NewDbTask(backupDbTask, dbApi))
 	}
 
-	if r.MigrateFrom != "" || r.BackupPath != "" {
+	if !r.isLogical() && (r.MigrateFrom != "" || r.BackupPath != "") {
 		dbTaskParams.Action = db.DbActionRestore
 		dbTaskParams.Data.DependsOn = []uuid.UUID{job_.Tasks[len(job_.Tasks)-1].UUID()}
 		restoreDbTask, err := dbApi.CreateDbTask(dbTaskParams, q)
@@ -0,0 +1,96 @@
+package grpc_agent
+
+import (
+	"fmt"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/handler/db_task"
+	"github.com/a-light-win/pg-helper/internal/job"
+	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// DailyBackupRequest is created by the DailyBackupScheduler,
+// it is not sent by the grpc server.
+type DailyBackupRequest struct {
+	Name   string
+	Reason string
+	JobId  uuid.UUID
+	// Prune the old backups after the daily backup is completed
+	Prune bool
+}
+
+func (r *DailyBackupRequest) Process(h *GrpcAgentHandler) error {
+	return h.DbApi.QueryWithRollback(func(tx pgx.Tx) error {
+		return r.process(h, tx)
+	})
+}
+
+func (r *DailyBackupRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
+	dbApi := h.DbApi
+	q := db.New(tx)
+
+	database, err := dbApi.GetDbByName(r.Name, q)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("Name", r.Name).
+			Msg("Daily backup database failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	if !database.IsReadyToUse() {
+		log.Debug().
+			Str("Name", r.Name).
+			Str("Stage", database.Stage.String()).
+			Str("Status", database.Status.String()).
+			Msg("Database is not ready to use, skip the daily backup")
+		return nil
+	}
+
+	if r.JobId == uuid.Nil {
+		r.JobId = uuid.New()
+	}
+
+	dbTaskParams := db.CreateDbTaskParams{
+		JobID:  r.JobId,
+		DbID:   database.ID,
+		DbName: database.Name,
+		Action: db.DbActionDailyBackup,
+		Reason: r.Reason,
+		Status: db.DbTaskStatusPending,
+		Data: db.DbTaskData{
+			BackupFrom:   dbApi.DbConfig.InstanceName,
+			BackupPath:   dbApi.DbConfig.NewBackupFile(r.Name),
+			BackupFormat: dbApi.DbConfig.BackupFormat,
+		},
+	}
+	backupTask, err := dbApi.CreateDbTask(&dbTaskParams, q)
+	if err != nil {
+		return err
+	}
+
+	job_ := &job.BaseJob{
+		ID:   r.JobId,
+		Name: fmt.Sprintf("DailyBackup-%s", r.Name),
+	}
+	job_.Tasks = append(job_.Tasks, db_task.NewDbTask(backupTask, dbApi))
+
+	if r.Prune {
+		// Only prune when the new backup is succeeded
+		dbTaskParams.Action = db.DbActionPruneBackups
+		dbTaskParams.Data = db.DbTaskData{DependsOn: []uuid.UUID{backupTask.ID}}
+		pruneTask, err := dbApi.CreateDbTask(&dbTaskParams, q)
+		if err != nil {
+			return err
+		}
+		job_.Tasks = append(job_.Tasks, db_task.NewDbTask(pruneTask, dbApi))
+	}
+
+	tx.Commit(dbApi.ConnCtx)
+
+	h.JobProducer.Send(job_)
+	return nil
+}
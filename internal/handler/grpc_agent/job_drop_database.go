@@ -0,0 +1,133 @@
+package grpc_agent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/handler/db_task"
+	"github.com/a-light-win/pg-helper/internal/job"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/utils"
+	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type DropDatabaseRequest struct {
+	*proto.DropDatabaseJob
+	JobId uuid.UUID
+}
+
+func NewDropDatabaseRequest(task *proto.DbJob) *DropDatabaseRequest {
+	return &DropDatabaseRequest{
+		DropDatabaseJob: task.GetDropDatabase(),
+		JobId:           utils.StringToUuid(task.JobId),
+	}
+}
+
+func (r *DropDatabaseRequest) Process(h *GrpcAgentHandler) error {
+	if h.DbApi.DbConfig.IsReservedName(r.Name) {
+		err := errors.New("the database name is Reserved")
+		log.Warn().Err(err).
+			Str("Name", r.Name).
+			Msg("Drop database failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	return h.DbApi.QueryWithRollback(func(tx pgx.Tx) error {
+		return r.process(h, tx)
+	})
+}
+
+func (r *DropDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
+	dbApi := h.DbApi
+	q := db.New(tx)
+
+	database, err := dbApi.GetDbByName(r.Name, q)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			log.Debug().
+				Str("Name", r.Name).
+				Msg("Database not found, nothing to drop")
+			return nil
+		}
+		log.Error().Err(err).
+			Str("Name", r.Name).
+			Msg("Drop database failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	if database.IsNotExist() || database.IsDropped() {
+		h.DbApi.NotifyDbStatusChanged(database)
+		return nil
+	}
+
+	// if JobId exists and equal to the LastJobId,
+	// resume the previous failed/cancelled tasks
+	// else new a JobId, and create new tasks.
+	if r.JobId != uuid.Nil && r.JobId == database.LastJobID {
+		return resumeJob(h, tx, r.JobId, fmt.Sprintf("DropDatabase-%s", r.Name), nil)
+	}
+
+	if !database.CanDrop() {
+		err := errors.New("database is not idle, can not drop")
+		log.Warn().Err(err).
+			Str("Name", r.Name).
+			Str("Stage", database.Stage.String()).
+			Str("Status", database.Status.String()).
+			Msg("Drop database failed")
+		h.DbApi.NotifyDbStatusChanged(database)
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	if r.JobId == uuid.Nil {
+		r.JobId = uuid.New()
+	}
+	database.LastJobID = r.JobId
+	if err := dbApi.UpdateDbStatus(database, q); err != nil {
+		return err
+	}
+
+	job_ := &job.BaseJob{
+		ID:   r.JobId,
+		Name: fmt.Sprintf("DropDatabase-%s", r.Name),
+	}
+
+	// Take a safety backup before the database is gone
+	dbTaskParams := &db.CreateDbTaskParams{
+		JobID:  r.JobId,
+		DbID:   database.ID,
+		DbName: database.Name,
+		Reason: r.Reason,
+		Action: db.DbActionBackup,
+		Status: db.DbTaskStatusPending,
+		Data: db.DbTaskData{
+			Owner:        database.Owner,
+			BackupFrom:   dbApi.DbConfig.InstanceName,
+			BackupPath:   dbApi.DbConfig.NewBackupFile(r.Name),
+			BackupFormat: dbApi.DbConfig.BackupFormat,
+			DropOwner:    r.DropOwner,
+		},
+	}
+	backupDbTask, err := dbApi.CreateDbTask(dbTaskParams, q)
+	if err != nil {
+		return err
+	}
+	job_.Tasks = append(job_.Tasks, db_task.NewDbTask(backupDbTask, dbApi))
+
+	dbTaskParams.Action = db.DbActionDrop
+	dbTaskParams.Data.DependsOn = []uuid.UUID{backupDbTask.ID}
+	dropDbTask, err := dbApi.CreateDbTask(dbTaskParams, q)
+	if err != nil {
+		return err
+	}
+	job_.Tasks = append(job_.Tasks, db_task.NewDbTask(dropDbTask, dbApi))
+
+	tx.Commit(dbApi.ConnCtx)
+
+	h.JobProducer.Send(job_)
+	return nil
+}
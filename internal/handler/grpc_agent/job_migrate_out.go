@@ -2,6 +2,8 @@ package grpc_agent
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/a-light-win/pg-helper/internal/db"
 	"github.com/a-light-win/pg-helper/internal/handler/db_task"
@@ -54,11 +56,10 @@ func (r *MigrateOutDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) erro
 	}
 
 	// if JobId exists and equal to the LastJobId,
-	// loading the previous failed/cancelled tasks and retry
+	// resume the previous failed/cancelled tasks
 	// else new a JobId, and create new tasks.
 	if r.JobId != uuid.Nil && r.JobId == database.LastJobID {
-		// TODO: Load last failed job and retry it from the first failed task
-		return nil
+		return resumeJob(h, tx, r.JobId, fmt.Sprintf("MigrateOutDatabase-%s", r.Name), nil)
 	}
 
 	if database.IsAlreadyIdle() {
@@ -78,16 +79,17 @@ func (r *MigrateOutDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) erro
 		return err
 	}
 
-	r.JobId = uuid.New()
+	if r.JobId == uuid.Nil {
+		r.JobId = uuid.New()
+	}
 	database.LastJobID = r.JobId
 	database.Stage = proto.DbStage_Idle
 	database.Status = proto.DbStatus_Processing
 	database.MigrateTo = r.MigrateTo
 	if r.ExpiredAt.IsValid() {
 		database.ExpiredAt.Scan(r.ExpiredAt.AsTime())
-	} else {
-		// TODO: set to default expired time?
-		// database.ExpiredAt(time.Now() + dbApi.DbConfig.DurationToDropIdleDb)
+	} else if dbApi.DbConfig.DurationToDropIdleDb > 0 {
+		database.ExpiredAt.Scan(time.Now().UTC().Add(dbApi.DbConfig.DurationToDropIdleDb))
 	}
 	h.DbApi.UpdateDbStatus(database, q)
 
@@ -106,7 +108,10 @@ func (r *MigrateOutDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) erro
 
 	tx.Commit(h.DbApi.ConnCtx)
 
-	job_ := &job.BaseJob{ID: r.JobId}
+	job_ := &job.BaseJob{
+		ID:   r.JobId,
+		Name: fmt.Sprintf("MigrateOutDatabase-%s", r.Name),
+	}
 	job_.Tasks = append(job_.Tasks, db_task.NewDbTask(&migrateOutTask, h.DbApi))
 
 	h.JobProducer.Send(job_)
@@ -0,0 +1,138 @@
+package grpc_agent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/handler/db_task"
+	"github.com/a-light-win/pg-helper/internal/job"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/utils"
+	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type RestoreDatabaseRequest struct {
+	*proto.RestoreDatabaseJob
+	JobId uuid.UUID
+}
+
+func NewRestoreDatabaseRequest(task *proto.DbJob) *RestoreDatabaseRequest {
+	return &RestoreDatabaseRequest{
+		RestoreDatabaseJob: task.GetRestoreDatabase(),
+		JobId:              utils.StringToUuid(task.JobId),
+	}
+}
+
+func (r *RestoreDatabaseRequest) Process(h *GrpcAgentHandler) error {
+	if _, err := h.DbApi.DbConfig.ValidateBackupPath(r.BackupPath, r.Name, nil); err != nil {
+		log.Warn().Err(err).
+			Str("Name", r.Name).
+			Str("BackupPath", r.BackupPath).
+			Msg("Restore database failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	return h.DbApi.QueryWithRollback(func(tx pgx.Tx) error {
+		return r.process(h, tx)
+	})
+}
+
+func (r *RestoreDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
+	dbApi := h.DbApi
+	q := db.New(tx)
+
+	database, err := dbApi.GetDbByName(r.Name, q)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("Name", r.Name).
+			Msg("Restore database failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	// if JobId exists and equal to the LastJobId,
+	// resume the previous failed/cancelled tasks
+	// else new a JobId, and create new tasks.
+	if r.JobId != uuid.Nil && r.JobId == database.LastJobID {
+		return resumeJob(h, tx, r.JobId, fmt.Sprintf("RestoreDatabase-%s", r.Name), nil)
+	}
+
+	if !database.CanRestoreInPlace() {
+		err := errors.New("database is not ready to use, can not restore")
+		log.Warn().Err(err).
+			Str("Name", r.Name).
+			Str("Stage", database.Stage.String()).
+			Str("Status", database.Status.String()).
+			Msg("Restore database failed")
+		h.DbApi.NotifyDbStatusChanged(database)
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	if r.JobId == uuid.Nil {
+		r.JobId = uuid.New()
+	}
+	database.LastJobID = r.JobId
+	if err := dbApi.UpdateDbStatus(database, q); err != nil {
+		return err
+	}
+
+	job_ := &job.BaseJob{
+		ID:   r.JobId,
+		Name: fmt.Sprintf("RestoreDatabase-%s", r.Name),
+	}
+
+	dbTaskParams := &db.CreateDbTaskParams{
+		JobID:  r.JobId,
+		DbID:   database.ID,
+		DbName: database.Name,
+		Reason: r.Reason,
+		Status: db.DbTaskStatusPending,
+	}
+
+	// Take a safety backup before the database is recreated,
+	// the database that failed in the previous restore has nothing to back up.
+	if database.IsReadyToUse() {
+		dbTaskParams.Action = db.DbActionBackup
+		dbTaskParams.Data = db.DbTaskData{
+			BackupFrom:   dbApi.DbConfig.InstanceName,
+			BackupPath:   dbApi.DbConfig.NewBackupFile(r.Name),
+			BackupFormat: dbApi.DbConfig.BackupFormat,
+		}
+		backupDbTask, err := dbApi.CreateDbTask(dbTaskParams, q)
+		if err != nil {
+			return err
+		}
+		job_.Tasks = append(job_.Tasks, db_task.NewDbTask(backupDbTask, dbApi))
+	}
+
+	dbTaskParams.Action = db.DbActionRestore
+	dbTaskParams.Data = db.DbTaskData{
+		BackupPath: r.BackupPath,
+		InPlace:    true,
+	}
+	if len(job_.Tasks) > 0 {
+		dbTaskParams.Data.DependsOn = []uuid.UUID{job_.Tasks[0].UUID()}
+	}
+	restoreDbTask, err := dbApi.CreateDbTask(dbTaskParams, q)
+	if err != nil {
+		return err
+	}
+	job_.Tasks = append(job_.Tasks, db_task.NewDbTask(restoreDbTask, dbApi))
+
+	dbTaskParams.Action = db.DbActionWaitReady
+	dbTaskParams.Data = db.DbTaskData{DependsOn: []uuid.UUID{restoreDbTask.ID}}
+	waitReadyTask, err := dbApi.CreateDbTask(dbTaskParams, q)
+	if err != nil {
+		return err
+	}
+	job_.Tasks = append(job_.Tasks, db_task.NewDbTask(waitReadyTask, dbApi))
+
+	tx.Commit(dbApi.ConnCtx)
+
+	h.JobProducer.Send(job_)
+	return nil
+}
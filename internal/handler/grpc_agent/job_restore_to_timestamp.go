@@ -0,0 +1,90 @@
+package grpc_agent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/handler/db_task"
+	"github.com/a-light-win/pg-helper/internal/job"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/utils"
+	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type RestoreToTimestampRequest struct {
+	*proto.RestoreToTimestampJob
+	JobId uuid.UUID
+}
+
+func NewRestoreToTimestampRequest(task *proto.DbJob) *RestoreToTimestampRequest {
+	return &RestoreToTimestampRequest{
+		RestoreToTimestampJob: task.GetRestoreToTimestamp(),
+		JobId:                 utils.StringToUuid(task.JobId),
+	}
+}
+
+func (r *RestoreToTimestampRequest) Process(h *GrpcAgentHandler) error {
+	if err := r.validate(); err != nil {
+		log.Warn().Err(err).
+			Str("DataDir", r.DataDir).
+			Msg("Restore to timestamp failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	return h.DbApi.QueryWithRollback(func(tx pgx.Tx) error {
+		return r.process(h, tx)
+	})
+}
+
+func (r *RestoreToTimestampRequest) validate() error {
+	if r.TargetTime == nil {
+		return errors.New("no target time")
+	}
+	if r.DataDir == "" {
+		return errors.New("no data dir")
+	}
+	return nil
+}
+
+func (r *RestoreToTimestampRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
+	dbApi := h.DbApi
+	q := db.New(tx)
+
+	if r.JobId == uuid.Nil {
+		r.JobId = uuid.New()
+	}
+
+	targetTime := r.TargetTime.AsTime()
+	dbTaskParams := db.CreateDbTaskParams{
+		JobID:  r.JobId,
+		DbID:   instanceDbID,
+		DbName: dbApi.DbConfig.InstanceName,
+		Action: db.DbActionRestoreToTimestamp,
+		Reason: r.Reason,
+		Status: db.DbTaskStatusPending,
+		Data: db.DbTaskData{
+			TargetTime: &targetTime,
+			DataDir:    r.DataDir,
+		},
+	}
+	restoreTask, err := dbApi.CreateDbTask(&dbTaskParams, q)
+	if err != nil {
+		return err
+	}
+
+	tx.Commit(dbApi.ConnCtx)
+
+	job_ := &job.BaseJob{
+		ID:   r.JobId,
+		Name: fmt.Sprintf("RestoreToTimestamp-%s", dbApi.DbConfig.InstanceName),
+	}
+	job_.Tasks = append(job_.Tasks, db_task.NewDbTask(restoreTask, dbApi))
+
+	h.JobProducer.Send(job_)
+	return nil
+}
@@ -0,0 +1,76 @@
+package grpc_agent
+
+import (
+	"fmt"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/handler/db_task"
+	"github.com/a-light-win/pg-helper/internal/job"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// resumeJob loads the tasks of the failed job, resets the failed and cancelled ones to pending,
+// then sends the job to the job handler again. The completed tasks are not run again.
+//
+// The fields that are not saved to the database (e.g. the password) can be restored by prepare.
+func resumeJob(h *GrpcAgentHandler, tx pgx.Tx, jobID uuid.UUID, name string, prepare func(task *db.DbTask)) error {
+	dbApi := h.DbApi
+	q := db.New(tx)
+
+	log := log.With().
+		Str("JobID", jobID.String()).
+		Str("JobName", name).
+		Logger()
+
+	tasks, err := q.ListDbTasksByJobID(dbApi.ConnCtx, jobID)
+	if err != nil && err != pgx.ErrNoRows {
+		log.Warn().Err(err).Msg("Failed to load the tasks of the job")
+		return err
+	}
+	if len(tasks) == 0 {
+		return fmt.Errorf("job %s has no task to resume", jobID)
+	}
+
+	job_ := &job.BaseJob{ID: jobID, Name: name}
+	for i := range tasks {
+		job_.Tasks = append(job_.Tasks, db_task.NewDbTask(&tasks[i], dbApi))
+	}
+
+	if !job_.IsDone() {
+		log.Debug().Msg("Job is still running, nothing to resume")
+		return nil
+	}
+	if !job_.IsFailed() {
+		log.Debug().Msg("Job is already completed, nothing to resume")
+		return nil
+	}
+
+	job_.Tasks = nil
+	for i := range tasks {
+		task := &tasks[i]
+		if task.Status == db.DbTaskStatusFailed || task.Status == db.DbTaskStatusCancelled {
+			task.Status = db.DbTaskStatusPending
+			task.Data.ErrReason = ""
+			if err := dbApi.SetTaskStatus(task, q); err != nil {
+				return err
+			}
+		}
+		if prepare != nil {
+			prepare(task)
+		}
+		job_.Tasks = append(job_.Tasks, db_task.NewDbTask(task, dbApi))
+	}
+
+	if err := tx.Commit(dbApi.ConnCtx); err != nil {
+		log.Warn().Err(err).Msg("Failed to reset the tasks of the job")
+		return err
+	}
+	dbApi.NotifyJobStatusChanged(jobID, nil)
+
+	log.Info().Msg("Resuming job from the first failed task")
+	job_.Init()
+	h.JobProducer.Send(job_)
+	return nil
+}
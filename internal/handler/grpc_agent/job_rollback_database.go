@@ -0,0 +1,107 @@
+package grpc_agent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/handler/db_task"
+	"github.com/a-light-win/pg-helper/internal/job"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/utils"
+	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type RollbackDatabaseRequest struct {
+	*proto.RollbackDatabaseJob
+	JobId uuid.UUID
+}
+
+func NewRollbackDatabaseRequest(task *proto.DbJob) *RollbackDatabaseRequest {
+	return &RollbackDatabaseRequest{
+		RollbackDatabaseJob: task.GetRollbackDatabase(),
+		JobId:               utils.StringToUuid(task.JobId),
+	}
+}
+
+func (r *RollbackDatabaseRequest) Process(h *GrpcAgentHandler) error {
+	return h.DbApi.QueryWithRollback(func(tx pgx.Tx) error {
+		return r.process(h, tx)
+	})
+}
+
+func (r *RollbackDatabaseRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
+	dbApi := h.DbApi
+	q := db.New(tx)
+
+	database, err := dbApi.GetDbByName(r.Name, q)
+	if err != nil {
+		log.Error().Err(err).
+			Str("Name", r.Name).
+			Msg("Rollback database failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	if database.IsReadyToUse() {
+		log.Debug().
+			Str("Name", r.Name).
+			Msg("Database is already ready to use")
+		h.DbApi.NotifyDbStatusChanged(database)
+		return nil
+	}
+
+	// if JobId exists and equal to the LastJobId,
+	// resume the previous failed/cancelled tasks
+	// else new a JobId, and create new tasks.
+	if r.JobId != uuid.Nil && r.JobId == database.LastJobID {
+		return resumeJob(h, tx, r.JobId, fmt.Sprintf("RollbackDatabase-%s", r.Name), nil)
+	}
+
+	if !database.CanRollback() {
+		err := errors.New("database is not idle, can not rollback")
+		log.Warn().Err(err).
+			Str("Name", r.Name).
+			Str("Stage", database.Stage.String()).
+			Str("Status", database.Status.String()).
+			Msg("Rollback database failed")
+		h.DbApi.NotifyDbStatusChanged(database)
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	if r.JobId == uuid.Nil {
+		r.JobId = uuid.New()
+	}
+	database.LastJobID = r.JobId
+	if err := dbApi.UpdateDbStatus(database, q); err != nil {
+		return err
+	}
+
+	dbTaskParams := db.CreateDbTaskParams{
+		JobID:  r.JobId,
+		DbID:   database.ID,
+		DbName: database.Name,
+		Action: db.DbActionRollback,
+		Reason: r.Reason,
+		Status: db.DbTaskStatusPending,
+		Data:   db.DbTaskData{},
+	}
+	rollbackTask, err := dbApi.CreateDbTask(&dbTaskParams, q)
+	if err != nil {
+		return err
+	}
+
+	tx.Commit(dbApi.ConnCtx)
+
+	job_ := &job.BaseJob{
+		ID:   r.JobId,
+		Name: fmt.Sprintf("RollbackDatabase-%s", r.Name),
+	}
+	job_.Tasks = append(job_.Tasks, db_task.NewDbTask(rollbackTask, dbApi))
+
+	h.JobProducer.Send(job_)
+	return nil
+}
@@ -0,0 +1,30 @@
+package grpc_agent
+
+import (
+	"context"
+
+	"github.com/a-light-win/pg-helper/internal/constants"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/server"
+)
+
+type JobStatusSender struct {
+	grpcClient proto.DbJobSvcClient
+	connCtx    context.Context
+}
+
+func (s *JobStatusSender) Handle(msg server.NamedElement) error {
+	_, err := s.grpcClient.NotifyJobStatus(s.connCtx, msg.(*proto.JobStatus))
+	return err
+}
+
+func (s *JobStatusSender) Init(setter server.GlobalSetter) error {
+	return nil
+}
+
+func (s *JobStatusSender) PostInit(getter server.GlobalGetter) error {
+	s.grpcClient = getter.Get(constants.AgentKeyGrpcClient).(proto.DbJobSvcClient)
+	s.connCtx = getter.Get(constants.AgentKeyConnCtx).(context.Context)
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package grpc_agent
+
+import (
+	"fmt"
+
+	"github.com/a-light-win/pg-helper/internal/db"
+	"github.com/a-light-win/pg-helper/internal/handler/db_task"
+	"github.com/a-light-win/pg-helper/internal/job"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/utils"
+	"github.com/a-light-win/pg-helper/pkg/utils/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type VerifyBackupRequest struct {
+	*proto.VerifyBackupJob
+	JobId uuid.UUID
+}
+
+func NewVerifyBackupRequest(task *proto.DbJob) *VerifyBackupRequest {
+	return &VerifyBackupRequest{
+		VerifyBackupJob: task.GetVerifyBackup(),
+		JobId:           utils.StringToUuid(task.JobId),
+	}
+}
+
+func (r *VerifyBackupRequest) Process(h *GrpcAgentHandler) error {
+	return h.DbApi.QueryWithRollback(func(tx pgx.Tx) error {
+		return r.process(h, tx)
+	})
+}
+
+func (r *VerifyBackupRequest) process(h *GrpcAgentHandler, tx pgx.Tx) error {
+	dbApi := h.DbApi
+	q := db.New(tx)
+
+	database, err := dbApi.GetDbByName(r.Name, q)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("Name", r.Name).
+			Msg("Verify backup failed")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	if r.BackupPath != "" {
+		if _, err := dbApi.DbConfig.ValidateBackupPath(r.BackupPath, r.Name, nil); err != nil {
+			log.Warn().Err(err).
+				Str("Name", r.Name).
+				Str("BackupPath", r.BackupPath).
+				Msg("Verify backup failed")
+			return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+		}
+	}
+
+	// The verification does not change the db stage,
+	// so it is not recorded as the last job of the db.
+	if r.JobId == uuid.Nil {
+		r.JobId = uuid.New()
+	}
+
+	dbTaskParams := db.CreateDbTaskParams{
+		JobID:  r.JobId,
+		DbID:   database.ID,
+		DbName: database.Name,
+		Action: db.DbActionVerifyBackup,
+		Reason: r.Reason,
+		Status: db.DbTaskStatusPending,
+		Data: db.DbTaskData{
+			BackupPath:       r.BackupPath,
+			VerifyAssertions: r.Assertions,
+		},
+	}
+	verifyTask, err := dbApi.CreateDbTask(&dbTaskParams, q)
+	if err != nil {
+		return err
+	}
+
+	tx.Commit(dbApi.ConnCtx)
+
+	job_ := &job.BaseJob{
+		ID:   r.JobId,
+		Name: fmt.Sprintf("VerifyBackup-%s", r.Name),
+	}
+	job_.Tasks = append(job_.Tasks, db_task.NewDbTask(verifyTask, dbApi))
+
+	h.JobProducer.Send(job_)
+	return nil
+}
@@ -10,6 +10,8 @@ import (
 
 type Database struct {
 	*proto.Database
+	// The result of the latest backup verification
+	LastVerification *proto.BackupVerification
 
 	Lock sync.Mutex
 	Cond *sync.Cond
@@ -57,11 +59,50 @@ func (d *Database) Update(db *proto.Database) bool {
 	return changed
 }
 
+func (d *Database) SetVerification(verification *proto.BackupVerification) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+
+	d.LastVerification = verification
+}
+
 func (d *Database) StatusResponse() *api.DbStatusResponse {
 	return &api.DbStatusResponse{
 		Name:      d.Name,
 		Stage:     d.Stage.String(),
 		Status:    d.Status.String(),
 		UpdatedAt: d.UpdatedAt.AsTime(),
+		ErrorMsg:  d.ErrorMsg,
+	}
+}
+
+func (d *Database) DbResponse() *api.DbResponse {
+	response := &api.DbResponse{
+		DbStatusResponse: *d.StatusResponse(),
+		Owner:            d.Owner,
+		MigrateFrom:      d.MigrateFrom,
+		MigrateTo:        d.MigrateTo,
+		LastJobId:        d.LastJobId,
+		CreatedAt:        d.CreatedAt.AsTime(),
+	}
+	if d.ExpiredAt != nil {
+		expiredAt := d.ExpiredAt.AsTime()
+		response.ExpiredAt = &expiredAt
+	}
+
+	d.Lock.Lock()
+	verification := d.LastVerification
+	d.Lock.Unlock()
+	if verification != nil {
+		response.LastVerification = &api.VerificationResponse{
+			JobId:            verification.JobId,
+			BackupPath:       verification.BackupPath,
+			Passed:           verification.Passed,
+			TableCount:       verification.TableCount,
+			FailedAssertions: verification.FailedAssertions,
+			ErrorMsg:         verification.ErrorMsg,
+			VerifiedAt:       verification.VerifiedAt.AsTime(),
+		}
 	}
+	return response
 }
@@ -8,8 +8,10 @@ import (
 	"time"
 
 	api "github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/a-light-win/pg-helper/internal/store"
 	"github.com/a-light-win/pg-helper/pkg/proto"
 	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 )
 
 type DbInstanceManager struct {
@@ -18,6 +20,10 @@ type DbInstanceManager struct {
 
 	dbSubscriber   *DbStatusSubscriber
 	InstSubscriber *InstanceStatusSubscriber
+
+	// Keeps the instance registry
+	store *store.Store
+	jobs  *JobHistory
 }
 
 func NewDbInstanceManager() *DbInstanceManager {
@@ -25,6 +31,7 @@ func NewDbInstanceManager() *DbInstanceManager {
 		Instances:      make(map[string]*DbInstance),
 		dbSubscriber:   &DbStatusSubscriber{},
 		InstSubscriber: &InstanceStatusSubscriber{},
+		jobs:           NewJobHistory(),
 	}
 }
 
@@ -54,14 +61,43 @@ func (m *DbInstanceManager) NewInstance(instName string, pgVersion int32, logger
 			return nil, err
 		}
 		inst.logger = logger
+		m.store.SaveInstance(instName, pgVersion)
 		return inst, nil
 	}
 
-	inst := NewDbInstance(instName, pgVersion, logger, m.dbSubscriber)
+	inst := NewDbInstance(instName, pgVersion, logger, m.dbSubscriber, m.jobs)
 	m.addInstance(inst)
+	m.store.SaveInstance(instName, pgVersion)
 	return inst, nil
 }
 
+// LoadInstances restores the registered instances from the store,
+// they are offline until the agents register again.
+func (m *DbInstanceManager) LoadInstances(store *store.Store) error {
+	m.instLock.Lock()
+	defer m.instLock.Unlock()
+
+	m.store = store
+	m.jobs.store = store
+	instances, err := store.LoadInstances()
+	if err != nil {
+		return err
+	}
+
+	for _, instance := range instances {
+		if m.instance(instance.Name) != nil {
+			continue
+		}
+		logger := log.With().
+			Str("DbInstance", instance.Name).
+			Int32("PgVersion", instance.PgVersion).
+			Logger()
+		m.addInstance(NewDbInstance(instance.Name, instance.PgVersion, &logger, m.dbSubscriber, m.jobs))
+	}
+	log.Debug().Int("Count", len(instances)).Msg("Load pg instances")
+	return nil
+}
+
 func (m *DbInstanceManager) addInstance(inst *DbInstance) {
 	m.Instances[inst.Name] = inst
 }
@@ -158,6 +194,58 @@ func (m *DbInstanceManager) GetDb(vo *api.DbRequest) (*proto.Database, error) {
 	return db.Database, nil
 }
 
+func (m *DbInstanceManager) ListInstances() []*api.InstanceResponse {
+	m.instLock.Lock()
+	defer m.instLock.Unlock()
+
+	instances := make([]*api.InstanceResponse, 0, len(m.Instances))
+	for _, inst := range m.Instances {
+		instances = append(instances, inst.InstanceResponse())
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Name < instances[j].Name
+	})
+	return instances
+}
+
+func (m *DbInstanceManager) ListDbs(request *api.ListDbsRequest) ([]*api.DbStatusResponse, error) {
+	inst := m.GetInstance(request.InstanceName)
+	if inst == nil {
+		return nil, api.ErrInstanceNotFound
+	}
+
+	status := inst.StatusResponse()
+	dbs := make([]*api.DbStatusResponse, 0, len(status.Databases))
+	for _, db := range status.Databases {
+		dbs = append(dbs, db)
+	}
+	sort.Slice(dbs, func(i, j int) bool {
+		return dbs[i].Name < dbs[j].Name
+	})
+	return dbs, nil
+}
+
+func (m *DbInstanceManager) GetDbDetail(request *api.GetDbRequest) (*api.DbResponse, error) {
+	inst := m.FirstMatchedInstance(&api.InstanceFilter{
+		InstanceName: request.InstanceName,
+		Name:         request.Name,
+		MustExist:    true,
+	})
+	if inst == nil {
+		return nil, api.ErrDbNotFound
+	}
+
+	db := inst.GetDb(request.Name)
+	if db == nil || db.IsNotExist() {
+		return nil, api.ErrDbNotFound
+	}
+
+	response := db.DbResponse()
+	response.InstanceName = inst.Name
+	response.Version = inst.PgVersion
+	return response, nil
+}
+
 func (m *DbInstanceManager) CreateDb(request *api.CreateDbRequest) error {
 	inst := m.FirstMatchedInstance(&request.InstanceFilter)
 	if inst == nil || !inst.Online {
@@ -176,17 +264,338 @@ func (m *DbInstanceManager) CreateDb(request *api.CreateDbRequest) error {
 			Reason:       request.Reason,
 			MigrateTo:    inst.Name,
 		}
+		if request.MigrateStrategy == proto.MigrateStrategyLogical {
+			// The old database keeps serving while it is replicated to the new instance,
+			// it goes idle after the cutover.
+			return inst.CreateDbThen(request,
+				func() error { return oldInst.MigrateOut(migrateOutRequest, func() error { return nil }) })
+		}
 		return oldInst.MigrateOut(migrateOutRequest,
 			func() error { return inst.CreateDb(request) })
 	}
 
+	if db := inst.GetDb(request.Name); db != nil && db.Stage == proto.DbStage_Idle {
+		// The database is moved back to the instance without migrate_from,
+		// the copy on the instance it was migrated to must be idle before the rollback.
+		return m.RollbackDb(&api.RollbackDbRequest{
+			Name:         request.Name,
+			InstanceName: inst.Name,
+			Reason:       request.Reason,
+		})
+	}
+
 	return inst.CreateDb(request)
 }
 
+// IdleDb marks the database as idle, the agent drops it after it is expired
+func (m *DbInstanceManager) IdleDb(request *api.IdleDbRequest) error {
+	inst := m.GetInstance(request.InstanceName)
+	if inst == nil || !inst.Online {
+		return api.ErrInstanceOffline
+	}
+
+	migrateOutRequest := &api.MigrateOutDbRequest{
+		Name:         request.Name,
+		InstanceName: request.InstanceName,
+		Reason:       request.Reason,
+		ExpireAt:     request.ExpireAt,
+	}
+	return inst.MigrateOut(migrateOutRequest, func() error { return nil })
+}
+
+// RollbackDb moves the idle database on request.InstanceName back to ReadyToUse,
+// the copy on the instance it was migrated to is marked as idle first.
+func (m *DbInstanceManager) RollbackDb(request *api.RollbackDbRequest) error {
+	inst := m.GetInstance(request.InstanceName)
+	if inst == nil || !inst.Online {
+		return api.ErrInstanceOffline
+	}
+
+	db := inst.GetDb(request.Name)
+	if db == nil || db.IsNotExist() {
+		return api.ErrDbNotFound
+	}
+	if db.IsReadyToUse() {
+		return nil
+	}
+
+	var newInst *DbInstance
+	if db.MigrateTo != "" && db.MigrateTo != inst.Name {
+		newInst = m.GetInstance(db.MigrateTo)
+	}
+
+	var newDb *Database
+	if newInst != nil {
+		newDb = newInst.GetDb(request.Name)
+	}
+	if newDb == nil || newDb.IsFailed() || newDb.IsReadyToMigrate() {
+		return inst.Rollback(request)
+	}
+
+	if !newDb.IsReadyToUse() {
+		return errors.New("database is still migrating, can not rollback")
+	}
+	if !newInst.Online {
+		return api.ErrInstanceOffline
+	}
+
+	migrateOutRequest := &api.MigrateOutDbRequest{
+		Name:         request.Name,
+		InstanceName: newInst.Name,
+		Reason:       request.Reason,
+		MigrateTo:    inst.Name,
+	}
+	return newInst.MigrateOut(migrateOutRequest,
+		func() error { return inst.Rollback(request) })
+}
+
+// VerifyBackup asks the agent to restore a backup of the database into a scratch database,
+// the result is reported back by the agent asynchronously.
+func (m *DbInstanceManager) VerifyBackup(request *api.VerifyBackupRequest) (*api.DbJobResponse, error) {
+	inst := m.GetInstance(request.InstanceName)
+	if inst == nil || !inst.Online {
+		return nil, api.ErrInstanceOffline
+	}
+
+	db := inst.GetDb(request.Name)
+	if db == nil || db.IsNotExist() {
+		return nil, api.ErrDbNotFound
+	}
+
+	jobId, err := inst.VerifyBackup(request)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DbJobResponse{
+		JobId:        jobId,
+		InstanceName: inst.Name,
+	}, nil
+}
+
+// BackupDb asks the agent to take an immediate backup of a ReadyToUse database,
+// the caller can poll the result by the returned job id.
+func (m *DbInstanceManager) BackupDb(request *api.BackupDbRequest) (*api.DbJobResponse, error) {
+	inst, err := m.readyToUseInstance(request.InstanceName, request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	jobId, err := inst.BackupDb(request)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DbJobResponse{
+		JobId:        jobId,
+		InstanceName: inst.Name,
+	}, nil
+}
+
+// RestoreDb asks the agent to restore a ReadyToUse database in place from a chosen backup,
+// the database goes through the RestoreDatabase stage and returns to ReadyToUse.
+// The database whose previous in-place restore failed can be restored again.
+func (m *DbInstanceManager) RestoreDb(request *api.RestoreDbRequest) (*api.DbJobResponse, error) {
+	inst, db, err := m.onlineDb(request.InstanceName, request.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !db.CanRestoreInPlace() {
+		return nil, errors.New("database is not ready to use")
+	}
+
+	if backup := inst.GetBackup(request.BackupPath); backup == nil || backup.Name != request.Name {
+		return nil, api.ErrBackupNotFound
+	}
+
+	jobId, err := inst.RestoreDb(request)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DbJobResponse{
+		JobId:        jobId,
+		InstanceName: inst.Name,
+	}, nil
+}
+
+// CloneDb asks the agent to create a new database as a copy of a ReadyToUse database,
+// from a backup in the catalog or from a backup of the live database taken now.
+func (m *DbInstanceManager) CloneDb(request *api.CloneDbRequest) (*api.DbJobResponse, error) {
+	inst, err := m.readyToUseInstance(request.InstanceName, request.SourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	if db := inst.GetDb(request.Name); db != nil && !db.IsNotExist() && !db.IsDropped() {
+		return nil, errors.New("database already exists")
+	}
+
+	if request.BackupPath != "" {
+		if backup := inst.GetBackup(request.BackupPath); backup == nil || backup.Name != request.SourceName {
+			return nil, api.ErrBackupNotFound
+		}
+	}
+
+	if request.ExpiredAt != nil && request.ExpiredAt.Before(time.Now()) {
+		return nil, errors.New("expired_at is in the past")
+	}
+
+	jobId, err := inst.CloneDb(request)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DbJobResponse{
+		JobId:        jobId,
+		InstanceName: inst.Name,
+	}, nil
+}
+
+// CancelJob asks the agent to cancel the job of the database,
+// the running tasks are interrupted and the pending tasks are cancelled.
+func (m *DbInstanceManager) CancelJob(request *api.CancelJobRequest) error {
+	inst := m.GetInstance(request.InstanceName)
+	if inst == nil || !inst.Online {
+		return api.ErrInstanceOffline
+	}
+
+	inst.CancelJob(request)
+	return nil
+}
+
+// readyToUseInstance returns the online instance that the database is ready to use in
+func (m *DbInstanceManager) readyToUseInstance(instName string, dbName string) (*DbInstance, error) {
+	inst, db, err := m.onlineDb(instName, dbName)
+	if err != nil {
+		return nil, err
+	}
+	if !db.IsReadyToUse() {
+		return nil, errors.New("database is not ready to use")
+	}
+	return inst, nil
+}
+
+// onlineDb returns the database and the online instance that it exists in
+func (m *DbInstanceManager) onlineDb(instName string, dbName string) (*DbInstance, *Database, error) {
+	inst := m.FirstMatchedInstance(&api.InstanceFilter{
+		InstanceName: instName,
+		Name:         dbName,
+		MustExist:    true,
+	})
+	if inst == nil || !inst.Online {
+		return nil, nil, api.ErrInstanceOffline
+	}
+
+	db := inst.GetDb(dbName)
+	if db == nil || db.IsNotExist() {
+		return nil, nil, api.ErrDbNotFound
+	}
+	return inst, db, nil
+}
+
+func (m *DbInstanceManager) BaseBackup(request *api.BaseBackupRequest) (*api.DbJobResponse, error) {
+	inst := m.GetInstance(request.InstanceName)
+	if inst == nil || !inst.Online {
+		return nil, api.ErrInstanceOffline
+	}
+
+	jobId, err := inst.BaseBackup(request)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DbJobResponse{
+		JobId:        jobId,
+		InstanceName: inst.Name,
+	}, nil
+}
+
+// RestoreToTimestamp asks the agent to prepare a point-in-time recovery in a new data directory,
+// a new instance should be started from the data directory to finish the recovery.
+func (m *DbInstanceManager) RestoreToTimestamp(request *api.RestoreToTimestampRequest) (*api.DbJobResponse, error) {
+	inst := m.GetInstance(request.InstanceName)
+	if inst == nil || !inst.Online {
+		return nil, api.ErrInstanceOffline
+	}
+
+	jobId, err := inst.RestoreToTimestamp(request)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DbJobResponse{
+		JobId:        jobId,
+		InstanceName: inst.Name,
+	}, nil
+}
+
+func (m *DbInstanceManager) GetRecoveryWindow(request *api.GetRecoveryWindowRequest) (*api.RecoveryWindowResponse, error) {
+	inst := m.GetInstance(request.InstanceName)
+	if inst == nil {
+		return nil, api.ErrInstanceNotFound
+	}
+
+	window := inst.GetRecoveryWindow()
+	if window == nil {
+		return nil, api.ErrRecoveryWindowNotFound
+	}
+	return api.NewRecoveryWindowResponse(window), nil
+}
+
+func (m *DbInstanceManager) ListBackups(filter *api.BackupFilter) []*api.BackupResponse {
+	var instances []*DbInstance
+	if filter.InstanceName != "" {
+		if inst := m.GetInstance(filter.InstanceName); inst != nil {
+			instances = append(instances, inst)
+		}
+	} else {
+		m.instLock.Lock()
+		for _, inst := range m.Instances {
+			instances = append(instances, inst)
+		}
+		m.instLock.Unlock()
+	}
+
+	backups := []*api.BackupResponse{}
+	for _, inst := range instances {
+		for _, backup := range inst.ListBackups(filter.Name) {
+			backups = append(backups, api.NewBackupResponse(backup))
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups
+}
+
+func (m *DbInstanceManager) GetBackup(request *api.BackupRequest) (*api.BackupResponse, error) {
+	inst := m.GetInstance(request.InstanceName)
+	if inst == nil {
+		return nil, errors.New("instance not found")
+	}
+
+	backup := inst.GetBackup(request.Path)
+	if backup == nil || backup.Name != request.Name {
+		return nil, api.ErrBackupNotFound
+	}
+	return api.NewBackupResponse(backup), nil
+}
+
+func (m *DbInstanceManager) UpdateJobStatus(jobStatus *proto.JobStatus) {
+	m.jobs.Update(jobStatus)
+}
+
+func (m *DbInstanceManager) GetJob(request *api.GetJobRequest) (*api.JobResponse, error) {
+	return m.jobs.Get(request)
+}
+
+func (m *DbInstanceManager) ListJobs(request *api.ListJobsRequest) ([]*api.JobResponse, error) {
+	return m.jobs.List(request)
+}
+
 func (m *DbInstanceManager) SubscribeDbStatus(callback api.SubscribeDbStatusFunc) {
 	m.dbSubscriber.Subscribe(callback)
 }
 
+func (m *DbInstanceManager) SubscribeDbTransitions(callback api.SubscribeDbStatusFunc) {
+	m.dbSubscriber.SubscribeTransitions(callback)
+}
+
 func (m *DbInstanceManager) SubscribeInstanceStatus(callback api.SubscribeInstanceStatusFunc) {
 	m.InstSubscriber.Subscribe(callback)
 }
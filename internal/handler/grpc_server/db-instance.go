@@ -8,6 +8,7 @@ import (
 	"github.com/a-light-win/pg-helper/pkg/proto"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type DbInstance struct {
@@ -19,23 +20,36 @@ type DbInstance struct {
 	// Protects Databases
 	dbLock sync.Mutex
 
+	// The backup catalog reported by the agent, indexed by the backup path
+	Backups map[string]*proto.Backup
+	// The latest recovery window reported by the agent,
+	// it is nil if the point-in-time recovery is disabled on the agent.
+	RecoveryWindow *proto.RecoveryWindow
+	// Protects Backups and RecoveryWindow
+	backupLock sync.Mutex
+
 	DbJobChan    chan *proto.DbJob
 	nonSentDbJob *proto.DbJob
 
 	logger *zerolog.Logger
 
 	subscriber *DbStatusSubscriber
+
+	// Records the jobs sent to the instance
+	jobs *JobHistory
 }
 
-func NewDbInstance(name string, pgVersion int32, logger *zerolog.Logger, subcriber *DbStatusSubscriber) *DbInstance {
+func NewDbInstance(name string, pgVersion int32, logger *zerolog.Logger, subcriber *DbStatusSubscriber, jobs *JobHistory) *DbInstance {
 	return &DbInstance{
 		Name:      name,
 		PgVersion: pgVersion,
 		Databases: make(map[string]*Database),
+		Backups:   make(map[string]*proto.Backup),
 		DbJobChan: make(chan *proto.DbJob),
 
 		logger:     logger,
 		subscriber: subcriber,
+		jobs:       jobs,
 	}
 }
 
@@ -133,6 +147,11 @@ func (a *DbInstance) ServeDbJob(s proto.DbJobSvc_RegisterServer) {
 }
 
 func (a *DbInstance) Send(job *proto.DbJob) {
+	if job.JobId == "" {
+		job.JobId = uuid.New().String()
+	}
+	a.jobs.Add(a.Name, job)
+
 	a.DbJobChan <- job
 }
 
@@ -142,37 +161,66 @@ func (a *DbInstance) CreateDb(vo *api.CreateDbRequest) error {
 
 	db := a.mustGetDb(vo.Name)
 
-	if db.Stage == proto.DbStage_DropDatabase {
+	if db.Stage == proto.DbStage_DropDatabase && !db.IsDropped() {
 		return errors.New("database is dropping")
 	}
 	if db.Stage == proto.DbStage_Idle {
-		// TODO: rollback to previous version here?
-		return errors.New("database is migrating out")
+		// The database is migrated back to this instance,
+		// the idle copy is still here, so just rollback it.
+		a.sendRollback(vo.Name, vo.Reason)
+		return nil
 	}
 
-	if db.Stage != proto.DbStage_None && !db.IsFailed() {
+	// The name of the dropped database (e.g. an expired ephemeral database) can be used again,
+	// the agent resets its record before creating it.
+	if db.Stage != proto.DbStage_None && !db.IsFailed() && !db.IsDropped() {
 		return nil
 	}
 
+	// The agent resumes the failed job from the first failed task
+	// if the job id is the last job of the database.
+	jobId := uuid.New().String()
+	if db.IsFailed() && db.LastJobId != "" {
+		jobId = db.LastJobId
+	}
+
 	job := &proto.DbJob{
-		JobId: uuid.New().String(),
+		JobId: jobId,
 		Job: &proto.DbJob_CreateDatabase{
 			CreateDatabase: &proto.CreateDatabaseJob{
-				Name:        vo.Name,
-				Reason:      vo.Reason,
-				Owner:       vo.Owner,
-				Password:    vo.Password,
-				MigrateFrom: vo.MigrateFrom,
-				BackupPath:  vo.BackupPath,
+				Name:            vo.Name,
+				Reason:          vo.Reason,
+				Owner:           vo.Owner,
+				Password:        vo.Password,
+				MigrateFrom:     vo.MigrateFrom,
+				BackupPath:      vo.BackupPath,
+				MigrateStrategy: vo.MigrateStrategy,
 			},
 		},
 	}
-	a.logger.Debug().Str("DbName", vo.Name).Msg("Job to create database")
+	a.logger.Debug().Str("DbName", vo.Name).Str("JobId", jobId).Msg("Job to create database")
 	a.Send(job)
 
 	return nil
 }
 
+// CreateDbThen creates the database, and calls the callback after it is ready to use
+func (a *DbInstance) CreateDbThen(vo *api.CreateDbRequest, callback func() error) error {
+	if db := a.GetDb(vo.Name); db != nil && db.IsReadyToUse() {
+		return callback()
+	}
+
+	a.subscriber.Subscribe(func(dbStatus *api.DbStatusResponse) bool {
+		if dbStatus.IsReady(vo.Name, a.Name) {
+			go callback()
+			return api.StopSubscribe
+		}
+		return api.ContinueSubscribe
+	})
+
+	return a.CreateDb(vo)
+}
+
 // Return true if send the migrateOut job
 func (a *DbInstance) MigrateOut(request *api.MigrateOutDbRequest, callback func() error) error {
 	a.dbLock.Lock()
@@ -193,19 +241,282 @@ func (a *DbInstance) MigrateOut(request *api.MigrateOutDbRequest, callback func(
 		return api.ContinueSubscribe
 	})
 
+	migrateOutJob := &proto.MigrateOutDatabaseJob{
+		Name:      request.Name,
+		Reason:    request.Reason,
+		MigrateTo: request.MigrateTo,
+	}
+	if !request.ExpireAt.IsZero() {
+		migrateOutJob.ExpiredAt = timestamppb.New(request.ExpireAt)
+	}
+
 	job := &proto.DbJob{
 		Job: &proto.DbJob_MigrateOutDatabase{
-			MigrateOutDatabase: &proto.MigrateOutDatabaseJob{
-				Name:      request.Name,
-				Reason:    request.Reason,
-				MigrateTo: request.MigrateTo,
+			MigrateOutDatabase: migrateOutJob,
+		},
+	}
+	a.Send(job)
+	return nil
+}
+
+func (a *DbInstance) Rollback(request *api.RollbackDbRequest) error {
+	a.dbLock.Lock()
+	defer a.dbLock.Unlock()
+
+	db, ok := a.Databases[request.Name]
+	if !ok || db.IsNotExist() {
+		return api.ErrDbNotFound
+	}
+	if db.IsReadyToUse() {
+		return nil
+	}
+	if db.Stage != proto.DbStage_Idle {
+		return errors.New("database is not idle, can not rollback")
+	}
+
+	a.sendRollback(request.Name, request.Reason)
+	return nil
+}
+
+func (a *DbInstance) sendRollback(name string, reason string) {
+	job := &proto.DbJob{
+		JobId: uuid.New().String(),
+		Job: &proto.DbJob_RollbackDatabase{
+			RollbackDatabase: &proto.RollbackDatabaseJob{
+				Name:   name,
+				Reason: reason,
+			},
+		},
+	}
+	a.logger.Debug().Str("DbName", name).Msg("Job to rollback database")
+	a.Send(job)
+}
+
+// VerifyBackup returns the id of the job that verifies the backup
+func (a *DbInstance) VerifyBackup(request *api.VerifyBackupRequest) (string, error) {
+	job := &proto.DbJob{
+		JobId: uuid.New().String(),
+		Job: &proto.DbJob_VerifyBackup{
+			VerifyBackup: &proto.VerifyBackupJob{
+				Name:       request.Name,
+				Reason:     request.Reason,
+				BackupPath: request.BackupPath,
+				Assertions: request.Assertions,
+			},
+		},
+	}
+	a.logger.Debug().Str("DbName", request.Name).Msg("Job to verify backup")
+	a.Send(job)
+	return job.JobId, nil
+}
+
+// BackupDb returns the id of the job that takes the backup
+func (a *DbInstance) BackupDb(request *api.BackupDbRequest) (string, error) {
+	job := &proto.DbJob{
+		JobId: uuid.New().String(),
+		Job: &proto.DbJob_BackupDatabase{
+			BackupDatabase: &proto.BackupDatabaseJob{
+				Name:   request.Name,
+				Reason: request.Reason,
 			},
 		},
 	}
+	a.logger.Debug().Str("DbName", request.Name).Msg("Job to backup database")
 	a.Send(job)
+	return job.JobId, nil
+}
+
+// RestoreDb returns the id of the job that restores the database
+func (a *DbInstance) RestoreDb(request *api.RestoreDbRequest) (string, error) {
+	job := &proto.DbJob{
+		JobId: uuid.New().String(),
+		Job: &proto.DbJob_RestoreDatabase{
+			RestoreDatabase: &proto.RestoreDatabaseJob{
+				Name:       request.Name,
+				Reason:     request.Reason,
+				BackupPath: request.BackupPath,
+			},
+		},
+	}
+	a.logger.Debug().
+		Str("DbName", request.Name).
+		Str("BackupPath", request.BackupPath).
+		Msg("Job to restore database")
+	a.Send(job)
+	return job.JobId, nil
+}
+
+// CloneDb returns the id of the job that clones the database
+func (a *DbInstance) CloneDb(request *api.CloneDbRequest) (string, error) {
+	cloneJob := &proto.CloneDatabaseJob{
+		Name:       request.Name,
+		Reason:     request.Reason,
+		Owner:      request.Owner,
+		Password:   request.Password,
+		SourceName: request.SourceName,
+		BackupPath: request.BackupPath,
+	}
+	if request.ExpiredAt != nil {
+		cloneJob.ExpiredAt = timestamppb.New(*request.ExpiredAt)
+	}
+
+	job := &proto.DbJob{
+		JobId: uuid.New().String(),
+		Job:   &proto.DbJob_CloneDatabase{CloneDatabase: cloneJob},
+	}
+	a.logger.Debug().
+		Str("DbName", request.Name).
+		Str("SourceName", request.SourceName).
+		Str("BackupPath", request.BackupPath).
+		Msg("Job to clone database")
+	a.Send(job)
+	return job.JobId, nil
+}
+
+func (a *DbInstance) CancelJob(request *api.CancelJobRequest) {
+	job := &proto.DbJob{
+		JobId: uuid.New().String(),
+		Job: &proto.DbJob_CancelJob{
+			CancelJob: &proto.CancelJob{
+				JobId:  request.JobId,
+				Name:   request.Name,
+				Reason: request.Reason,
+			},
+		},
+	}
+	a.logger.Debug().
+		Str("DbName", request.Name).
+		Str("CancelJobId", request.JobId).
+		Msg("Job to cancel a job")
+	a.Send(job)
+}
+
+// BaseBackup returns the id of the job that takes the base backup
+func (a *DbInstance) BaseBackup(request *api.BaseBackupRequest) (string, error) {
+	job := &proto.DbJob{
+		JobId: uuid.New().String(),
+		Job: &proto.DbJob_BaseBackup{
+			BaseBackup: &proto.BaseBackupJob{
+				Reason: request.Reason,
+			},
+		},
+	}
+	a.logger.Debug().Msg("Job to take a base backup")
+	a.Send(job)
+	return job.JobId, nil
+}
+
+// RestoreToTimestamp returns the id of the job that prepares the recovery
+func (a *DbInstance) RestoreToTimestamp(request *api.RestoreToTimestampRequest) (string, error) {
+	job := &proto.DbJob{
+		JobId: uuid.New().String(),
+		Job: &proto.DbJob_RestoreToTimestamp{
+			RestoreToTimestamp: &proto.RestoreToTimestampJob{
+				Reason:     request.Reason,
+				TargetTime: timestamppb.New(request.TargetTime),
+				DataDir:    request.DataDir,
+			},
+		},
+	}
+	a.logger.Debug().
+		Time("TargetTime", request.TargetTime).
+		Str("DataDir", request.DataDir).
+		Msg("Job to restore to timestamp")
+	a.Send(job)
+	return job.JobId, nil
+}
+
+func (a *DbInstance) UpdateRecoveryWindow(window *proto.RecoveryWindow) {
+	a.logger.Debug().
+		Bool("Available", window.Available).
+		Str("StartWalFile", window.StartWalFile).
+		Str("EndWalFile", window.EndWalFile).
+		Msg("Recovery window updated")
+
+	a.backupLock.Lock()
+	defer a.backupLock.Unlock()
+	a.RecoveryWindow = window
+}
+
+func (a *DbInstance) GetRecoveryWindow() *proto.RecoveryWindow {
+	a.backupLock.Lock()
+	defer a.backupLock.Unlock()
+	return a.RecoveryWindow
+}
+
+func (a *DbInstance) UpdateBackupVerification(verification *proto.BackupVerification) {
+	a.logger.Info().
+		Str("DbName", verification.Name).
+		Str("BackupPath", verification.BackupPath).
+		Bool("Passed", verification.Passed).
+		Int64("TableCount", verification.TableCount).
+		Str("ErrorMsg", verification.ErrorMsg).
+		Msg("Backup verified")
+
+	a.MustGetDb(verification.Name).SetVerification(verification)
+
+	// The agent only reports the result, keep the backup catalog in sync with it
+	a.backupLock.Lock()
+	defer a.backupLock.Unlock()
+	if backup, ok := a.Backups[verification.BackupPath]; ok {
+		backup.VerifiedAt = verification.VerifiedAt
+		backup.VerifyPassed = verification.Passed
+	}
+}
+
+// UpdateBackups replaces the backup catalog with the one reported on register
+func (a *DbInstance) UpdateBackups(backups []*proto.Backup) {
+	a.backupLock.Lock()
+	defer a.backupLock.Unlock()
+
+	a.Backups = make(map[string]*proto.Backup, len(backups))
+	for _, backup := range backups {
+		a.Backups[backup.Path] = backup
+	}
+	a.logger.Debug().Int("Count", len(backups)).Msg("Init backup catalog")
+}
+
+func (a *DbInstance) UpdateBackup(backup *proto.Backup) {
+	a.backupLock.Lock()
+	defer a.backupLock.Unlock()
+
+	a.logger.Debug().
+		Str("DbName", backup.Name).
+		Str("BackupPath", backup.Path).
+		Bool("Deleted", backup.Deleted).
+		Msg("Update backup catalog")
+
+	if backup.Deleted {
+		delete(a.Backups, backup.Path)
+		return
+	}
+	a.Backups[backup.Path] = backup
+}
+
+func (a *DbInstance) GetBackup(path string) *proto.Backup {
+	a.backupLock.Lock()
+	defer a.backupLock.Unlock()
+
+	if backup, ok := a.Backups[path]; ok {
+		return backup
+	}
 	return nil
 }
 
+// ListBackups returns the backups of the database, or all backups if dbName is empty
+func (a *DbInstance) ListBackups(dbName string) []*proto.Backup {
+	a.backupLock.Lock()
+	defer a.backupLock.Unlock()
+
+	var backups []*proto.Backup
+	for _, backup := range a.Backups {
+		if dbName == "" || backup.Name == dbName {
+			backups = append(backups, backup)
+		}
+	}
+	return backups
+}
+
 func (a *DbInstance) StatusResponse() *api.InstanceStatusResponse {
 	a.dbLock.Lock()
 	defer a.dbLock.Unlock()
@@ -213,6 +524,10 @@ func (a *DbInstance) StatusResponse() *api.InstanceStatusResponse {
 	databases := make(map[string]*api.DbStatusResponse)
 	if a.Online {
 		for _, db := range a.Databases {
+			// The database is requested but not reported by the agent yet
+			if db.IsNotExist() {
+				continue
+			}
 			dbStatus := db.StatusResponse()
 			dbStatus.InstanceName = a.Name
 			dbStatus.Version = a.PgVersion
@@ -228,3 +543,11 @@ func (a *DbInstance) StatusResponse() *api.InstanceStatusResponse {
 		Databases: databases,
 	}
 }
+
+func (a *DbInstance) InstanceResponse() *api.InstanceResponse {
+	return &api.InstanceResponse{
+		Name:    a.Name,
+		Version: a.PgVersion,
+		Online:  a.Online,
+	}
+}
@@ -8,7 +8,9 @@ import (
 
 type DbStatusSubscriber struct {
 	subscribers []api.SubscribeDbStatusFunc
-	mutex       sync.Mutex
+	// The subscribers that are notified on every stage or status change
+	transitionSubscribers []api.SubscribeDbStatusFunc
+	mutex                 sync.Mutex
 }
 
 func (s *DbStatusSubscriber) Subscribe(subscriber api.SubscribeDbStatusFunc) {
@@ -17,15 +19,17 @@ func (s *DbStatusSubscriber) Subscribe(subscriber api.SubscribeDbStatusFunc) {
 	s.subscribers = append(s.subscribers, subscriber)
 }
 
-func (s *DbStatusSubscriber) OnStatusChanged(instance *DbInstance, db *Database) {
-	if !db.IsFailed() && !db.IsSynced() {
-		return
-	}
+func (s *DbStatusSubscriber) SubscribeTransitions(subscriber api.SubscribeDbStatusFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.transitionSubscribers = append(s.transitionSubscribers, subscriber)
+}
 
+func (s *DbStatusSubscriber) OnStatusChanged(instance *DbInstance, db *Database) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	if len(s.subscribers) == 0 {
+	if len(s.subscribers) == 0 && len(s.transitionSubscribers) == 0 {
 		return
 	}
 
@@ -33,14 +37,20 @@ func (s *DbStatusSubscriber) OnStatusChanged(instance *DbInstance, db *Database)
 	dbStatus.InstanceName = instance.Name
 	dbStatus.Version = instance.PgVersion
 
-	s.notifyStatusChanged(dbStatus)
+	s.transitionSubscribers = notifyStatusChanged(s.transitionSubscribers, dbStatus)
+
+	if !db.IsFailed() && !db.IsSynced() {
+		return
+	}
+	s.subscribers = notifyStatusChanged(s.subscribers, dbStatus)
 }
 
-func (s *DbStatusSubscriber) notifyStatusChanged(dbStatus *api.DbStatusResponse) {
-	for i := 0; i < len(s.subscribers); i++ {
-		if !s.subscribers[i](dbStatus) {
-			s.subscribers = append(s.subscribers[:i], s.subscribers[i+1:]...)
+func notifyStatusChanged(subscribers []api.SubscribeDbStatusFunc, dbStatus *api.DbStatusResponse) []api.SubscribeDbStatusFunc {
+	for i := 0; i < len(subscribers); i++ {
+		if !subscribers[i](dbStatus) {
+			subscribers = append(subscribers[:i], subscribers[i+1:]...)
 			i--
 		}
 	}
+	return subscribers
 }
@@ -7,6 +7,7 @@ import (
 
 	config "github.com/a-light-win/pg-helper/internal/config/server"
 	"github.com/a-light-win/pg-helper/internal/constants"
+	"github.com/a-light-win/pg-helper/internal/store"
 	grpcAuth "github.com/a-light-win/pg-helper/pkg/auth/grpc"
 	"github.com/a-light-win/pg-helper/pkg/proto"
 	"github.com/a-light-win/pg-helper/pkg/server"
@@ -78,9 +79,13 @@ func (s *GrpcServer) Shutdown(ctx context.Context) {
 func (s *GrpcServer) Init(setter server.GlobalSetter) error {
 	setter.Set(constants.ServerKeyDbManager, s.SvcHandler.DbInstanceManager)
 	setter.Set(constants.ServerKeyDbReadyWaiter, s.SvcHandler.DbInstanceManager)
+	setter.Set(constants.ServerKeyBackupCatalog, s.SvcHandler.DbInstanceManager)
+	setter.Set(constants.ServerKeyInstanceCatalog, s.SvcHandler.DbInstanceManager)
+	setter.Set(constants.ServerKeyJobCatalog, s.SvcHandler.DbInstanceManager)
 	return nil
 }
 
 func (s *GrpcServer) PostInit(getter server.GlobalGetter) error {
-	return nil
+	store := getter.Get(constants.ServerKeyStore).(*store.Store)
+	return s.SvcHandler.LoadInstances(store)
 }
@@ -0,0 +1,186 @@
+package grpc_server
+
+import (
+	"sync"
+	"time"
+
+	api "github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/a-light-win/pg-helper/internal/store"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+)
+
+const (
+	// The max count of the jobs kept in memory if there is no store
+	maxJobsInMemory = 1024
+	// The default count of the jobs returned by ListJobs
+	defaultJobsLimit = 20
+)
+
+// JobHistory keeps the jobs sent to the agents and the progress reported by them.
+//
+// The jobs are saved to the store if it is enabled,
+// otherwise only the latest jobs are kept in memory.
+type JobHistory struct {
+	store *store.Store
+
+	jobs map[string]*api.JobResponse
+	// The job ids in the order they are added, the oldest first
+	jobIds []string
+	// The latest time the tasks of the job are updated on the agent
+	reportedAt map[string]time.Time
+	lock       sync.Mutex
+}
+
+func NewJobHistory() *JobHistory {
+	return &JobHistory{
+		jobs:       make(map[string]*api.JobResponse),
+		reportedAt: make(map[string]time.Time),
+	}
+}
+
+// Add records the job sent to the instance
+func (h *JobHistory) Add(instanceName string, job *proto.DbJob) {
+	if h.store.Enabled() {
+		h.store.SaveJob(instanceName, job)
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// The failed job is resumed with the same id
+	if _, ok := h.jobs[job.JobId]; ok {
+		return
+	}
+
+	now := time.Now()
+	h.add(&api.JobResponse{
+		JobId:        job.JobId,
+		InstanceName: instanceName,
+		DbName:       job.DbName(),
+		Type:         job.JobType(),
+		Reason:       job.Reason(),
+		Status:       api.JobStatusPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Tasks:        []*api.TaskResponse{},
+	})
+}
+
+func (h *JobHistory) add(job *api.JobResponse) {
+	h.jobs[job.JobId] = job
+	h.jobIds = append(h.jobIds, job.JobId)
+
+	for len(h.jobIds) > maxJobsInMemory {
+		delete(h.jobs, h.jobIds[0])
+		delete(h.reportedAt, h.jobIds[0])
+		h.jobIds = h.jobIds[1:]
+	}
+}
+
+// Update saves the progress of the job reported by the agent
+func (h *JobHistory) Update(jobStatus *proto.JobStatus) {
+	tasks := make([]*api.TaskResponse, 0, len(jobStatus.Tasks))
+	reportedAt := time.Time{}
+	for _, task := range jobStatus.Tasks {
+		taskResponse := &api.TaskResponse{
+			TaskId:    task.TaskId,
+			DbName:    task.DbName,
+			Action:    task.Action,
+			Reason:    task.Reason,
+			Status:    task.Status,
+			ErrReason: task.ErrReason,
+			CreatedAt: task.CreatedAt.AsTime(),
+			UpdatedAt: task.UpdatedAt.AsTime(),
+		}
+		if taskResponse.UpdatedAt.After(reportedAt) {
+			reportedAt = taskResponse.UpdatedAt
+		}
+		tasks = append(tasks, taskResponse)
+	}
+
+	job := &api.JobResponse{
+		JobId:        jobStatus.JobId,
+		InstanceName: jobStatus.InstanceName,
+		Status:       api.JobStatusOf(tasks),
+		Tasks:        tasks,
+	}
+	if len(tasks) > 0 {
+		job.DbName = tasks[0].DbName
+		job.Reason = tasks[0].Reason
+	}
+
+	if h.store.Enabled() {
+		h.store.SaveJobStatus(job, reportedAt)
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	oldJob, ok := h.jobs[job.JobId]
+	if !ok {
+		// The job is started by the agent itself
+		job.CreatedAt = time.Now()
+		job.UpdatedAt = job.CreatedAt
+		h.add(job)
+		h.reportedAt[job.JobId] = reportedAt
+		return
+	}
+
+	if reportedAt.Before(h.reportedAt[job.JobId]) {
+		return
+	}
+	h.reportedAt[job.JobId] = reportedAt
+	oldJob.Status = job.Status
+	oldJob.Tasks = job.Tasks
+	oldJob.UpdatedAt = time.Now()
+}
+
+func (h *JobHistory) Get(request *api.GetJobRequest) (*api.JobResponse, error) {
+	var job *api.JobResponse
+	if h.store.Enabled() {
+		var err error
+		if job, err = h.store.GetJob(request.JobId); err != nil {
+			return nil, err
+		}
+	} else {
+		h.lock.Lock()
+		if job_, ok := h.jobs[request.JobId]; ok {
+			// The job in memory is changed by the later reports
+			copied := *job_
+			job = &copied
+		}
+		h.lock.Unlock()
+	}
+
+	// Only the job of the authorized database can be seen,
+	// the name is omitted when the caller is authorized to all the databases.
+	if job == nil || (request.Name != "" && job.DbName != request.Name) {
+		return nil, api.ErrJobNotFound
+	}
+	return job, nil
+}
+
+func (h *JobHistory) List(request *api.ListJobsRequest) ([]*api.JobResponse, error) {
+	limit := request.Limit
+	if limit <= 0 {
+		limit = defaultJobsLimit
+	}
+
+	if h.store.Enabled() {
+		return h.store.ListJobs(request.Name, limit)
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	jobs := []*api.JobResponse{}
+	for i := len(h.jobIds) - 1; i >= 0 && len(jobs) < int(limit); i-- {
+		if job := h.jobs[h.jobIds[i]]; job.DbName == request.Name {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,35 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+
+	grpcAuth "github.com/a-light-win/pg-helper/pkg/auth/grpc"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (h *DbJobSvcHandler) NotifyJobStatus(ctx context.Context, jobStatus *proto.JobStatus) (*emptypb.Empty, error) {
+	authInfo, ok := grpcAuth.LoadAuthInfo(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "no auth info")
+	}
+	if !authInfo.ValidateScope("agent") {
+		return nil, status.Error(codes.PermissionDenied, "no scope permission")
+	}
+	if !authInfo.ValidateResource("dbInstance:" + jobStatus.InstanceName) {
+		return nil, status.Error(codes.PermissionDenied, "no resource permission")
+	}
+
+	if h.GetInstance(jobStatus.InstanceName) == nil {
+		err := errors.New("db instance not found")
+		log.Warn().Err(err).Str("InstanceName", jobStatus.InstanceName).Msg("")
+		return nil, err
+	}
+
+	h.UpdateJobStatus(jobStatus)
+	return &emptypb.Empty{}, nil
+}
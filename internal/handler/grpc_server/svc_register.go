@@ -42,6 +42,7 @@ func (h *DbJobSvcHandler) Register(m *proto.RegisterInstance, s proto.DbJobSvc_R
 	logger.Log().Msg("Instance registered.")
 
 	instance.UpdateDatabases(m.Databases)
+	instance.UpdateBackups(m.Backups)
 
 	instance.Online = true
 	h.InstSubscriber.OnStatusChanged(instance)
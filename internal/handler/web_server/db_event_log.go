@@ -0,0 +1,152 @@
+package web_server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+)
+
+const (
+	DbEventDb       = "db"
+	DbEventInstance = "instance"
+
+	// The count of the latest events kept to resume the watchers
+	maxDbEvents = 1024
+)
+
+// DbEvent is pushed to the watchers when the status of a database or an instance is changed
+type DbEvent struct {
+	Seq   uint64
+	Event string
+
+	Db       *grpcServerApi.DbStatusResponse
+	Instance *grpcServerApi.InstanceResponse
+}
+
+func (e *DbEvent) Data() interface{} {
+	if e.Event == DbEventInstance {
+		return e.Instance
+	}
+	return e.Db
+}
+
+// DbEventLog keeps the latest events in memory,
+// so the watcher can resume from the last event it received.
+type DbEventLog struct {
+	// The event ids are prefixed by the epoch,
+	// the events before the server restarted can not be resumed.
+	epoch  int64
+	events []*DbEvent
+	seq    uint64
+
+	// changed is closed and replaced when a new event is added
+	changed chan struct{}
+	// closed is closed when the web server is shutting down
+	closed chan struct{}
+	lock   sync.Mutex
+}
+
+func NewDbEventLog() *DbEventLog {
+	return &DbEventLog{
+		epoch:   time.Now().Unix(),
+		changed: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (l *DbEventLog) Subscribe(dbManager grpcServerApi.DbManager) {
+	dbManager.SubscribeDbTransitions(func(dbStatus *grpcServerApi.DbStatusResponse) bool {
+		l.add(&DbEvent{Event: DbEventDb, Db: dbStatus})
+		return grpcServerApi.ContinueSubscribe
+	})
+	dbManager.SubscribeInstanceStatus(func(instStatus *grpcServerApi.InstanceStatusResponse) bool {
+		l.add(&DbEvent{
+			Event: DbEventInstance,
+			Instance: &grpcServerApi.InstanceResponse{
+				Name:    instStatus.Name,
+				Version: instStatus.Version,
+				Online:  instStatus.Online,
+			},
+		})
+		return grpcServerApi.ContinueSubscribe
+	})
+}
+
+func (l *DbEventLog) add(event *DbEvent) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.seq++
+	event.Seq = l.seq
+	l.events = append(l.events, event)
+	if len(l.events) > maxDbEvents {
+		l.events = l.events[len(l.events)-maxDbEvents:]
+	}
+
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// Since returns the events after the seq,
+// and a channel that is closed when the next event is added.
+func (l *DbEventLog) Since(seq uint64) ([]*DbEvent, <-chan struct{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var events []*DbEvent
+	for _, event := range l.events {
+		if event.Seq > seq {
+			events = append(events, event)
+		}
+	}
+	return events, l.changed
+}
+
+// LastSeq returns the seq of the latest event
+func (l *DbEventLog) LastSeq() uint64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.seq
+}
+
+func (l *DbEventLog) Closed() <-chan struct{} {
+	return l.closed
+}
+
+func (l *DbEventLog) Close() {
+	close(l.closed)
+}
+
+// EventId returns the id of the event that is sent as the SSE id
+func (l *DbEventLog) EventId(seq uint64) string {
+	return fmt.Sprintf("%d-%d", l.epoch, seq)
+}
+
+// ParseEventId returns the seq of the event id,
+// ok is false if the events after it can not be resumed,
+// e.g. the id is generated before the server restarted or the events are dropped.
+func (l *DbEventLog) ParseEventId(id string) (seq uint64, ok bool) {
+	epoch, seqStr, found := strings.Cut(id, "-")
+	if !found || epoch != strconv.FormatInt(l.epoch, 10) {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if seq > l.seq {
+		return 0, false
+	}
+	if len(l.events) > 0 && seq+1 < l.events[0].Seq {
+		return 0, false
+	}
+	return seq, true
+}
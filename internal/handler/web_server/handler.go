@@ -8,6 +8,7 @@ import (
 	"github.com/a-light-win/pg-helper/internal/interface/sourceApi"
 	ginAuth "github.com/a-light-win/pg-helper/pkg/auth/gin"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/rs/zerolog/log"
 )
 
@@ -32,6 +33,18 @@ type (
 func WebHandleWrapper(handler WebHandler, newRequestFunc NewWebRequestFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		request := newRequestFunc()
+		// The path parameters, e.g. /api/v1/db/:name,
+		// they are validated together with the other fields by ShouldBind
+		if len(c.Params) > 0 {
+			params := make(map[string][]string, len(c.Params))
+			for _, param := range c.Params {
+				params[param.Key] = []string{param.Value}
+			}
+			if err := binding.MapFormWithTag(request, params, "uri"); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
 		if err := c.ShouldBind(request); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -80,15 +93,39 @@ func authCheck(c *gin.Context, request WebRequest) error {
 type DbHandler struct {
 	SourceHandler sourceApi.SourceHandler
 	ReadyWaiter   grpcServerApi.DbReadyWaiter
+	BackupCatalog grpcServerApi.BackupCatalog
+	DbManager     grpcServerApi.DbManager
+	InstCatalog   grpcServerApi.InstanceCatalog
+	JobCatalog    grpcServerApi.JobCatalog
 }
 
-func NewDbHandler(sourceHandler sourceApi.SourceHandler, readyWaiter grpcServerApi.DbReadyWaiter) *DbHandler {
+func NewDbHandler(sourceHandler sourceApi.SourceHandler, readyWaiter grpcServerApi.DbReadyWaiter, backupCatalog grpcServerApi.BackupCatalog, dbManager grpcServerApi.DbManager, instCatalog grpcServerApi.InstanceCatalog, jobCatalog grpcServerApi.JobCatalog) *DbHandler {
 	return &DbHandler{
 		SourceHandler: sourceHandler,
 		ReadyWaiter:   readyWaiter,
+		BackupCatalog: backupCatalog,
+		DbManager:     dbManager,
+		InstCatalog:   instCatalog,
+		JobCatalog:    jobCatalog,
 	}
 }
 
 func (h *DbHandler) GetName() string {
 	return "Database Handler"
 }
+
+type WatchHandler struct {
+	DbEvents    *DbEventLog
+	InstCatalog grpcServerApi.InstanceCatalog
+}
+
+func NewWatchHandler(dbEvents *DbEventLog, instCatalog grpcServerApi.InstanceCatalog) *WatchHandler {
+	return &WatchHandler{
+		DbEvents:    dbEvents,
+		InstCatalog: instCatalog,
+	}
+}
+
+func (h *WatchHandler) GetName() string {
+	return "Watch Handler"
+}
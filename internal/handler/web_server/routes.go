@@ -4,9 +4,37 @@ func (w *WebServer) registerRoutes() {
 	dbGroup := w.Router.Group("/api/v1/db")
 	dbGroup.Use(w.Auth.AuthMiddleware)
 
-	dbHandler := NewDbHandler(w.sourceHandler, w.dbReadyWaiter)
+	dbHandler := NewDbHandler(w.sourceHandler, w.dbReadyWaiter, w.backupCatalog, w.dbManager, w.instCatalog, w.jobCatalog)
+
+	watchHandler := NewWatchHandler(w.dbEvents, w.instCatalog)
 
-	// TODO: Get task status
 	dbGroup.GET("/ready", WebHandleWrapper(dbHandler, NewIsDbReadyRequest))
+	dbGroup.GET("/watch", WebHandleWrapper(watchHandler, NewWatchDbsRequest))
 	dbGroup.POST("", WebHandleWrapper(dbHandler, NewCreateDbRequest))
+	dbGroup.GET("/backups", WebHandleWrapper(dbHandler, NewListBackupsRequest))
+	dbGroup.GET("/backup", WebHandleWrapper(dbHandler, NewGetBackupRequest))
+	dbGroup.POST("/backup", WebHandleWrapper(dbHandler, NewBackupDbRequest))
+	dbGroup.POST("/verify", WebHandleWrapper(dbHandler, NewVerifyBackupRequest))
+	dbGroup.POST("/restore", WebHandleWrapper(dbHandler, NewRestoreDbRequest))
+	dbGroup.POST("/clone", WebHandleWrapper(dbHandler, NewCloneDbRequest))
+	dbGroup.POST("/rollback", WebHandleWrapper(dbHandler, NewRollbackDbRequest))
+	dbGroup.POST("/cancel", WebHandleWrapper(dbHandler, NewCancelJobRequest))
+	dbGroup.GET("/:name", WebHandleWrapper(dbHandler, NewGetDbRequest))
+	dbGroup.PATCH("/:name", WebHandleWrapper(dbHandler, NewUpdateDbRequest))
+	dbGroup.DELETE("/:name", WebHandleWrapper(dbHandler, NewDeleteDbRequest))
+	dbGroup.GET("/:name/jobs", WebHandleWrapper(dbHandler, NewListJobsRequest))
+
+	instGroup := w.Router.Group("/api/v1/instances")
+	instGroup.Use(w.Auth.AuthMiddleware)
+
+	instGroup.GET("", WebHandleWrapper(dbHandler, NewListInstancesRequest))
+	instGroup.GET("/:instance_name/dbs", WebHandleWrapper(dbHandler, NewListDbsRequest))
+	instGroup.GET("/:instance_name/recovery-window", WebHandleWrapper(dbHandler, NewGetRecoveryWindowRequest))
+	instGroup.POST("/:instance_name/base-backup", WebHandleWrapper(dbHandler, NewBaseBackupRequest))
+	instGroup.POST("/:instance_name/restore-to-timestamp", WebHandleWrapper(dbHandler, NewRestoreToTimestampRequest))
+
+	jobGroup := w.Router.Group("/api/v1/jobs")
+	jobGroup.Use(w.Auth.AuthMiddleware)
+
+	jobGroup.GET("/:id", WebHandleWrapper(dbHandler, NewGetJobRequest))
 }
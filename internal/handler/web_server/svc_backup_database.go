@@ -0,0 +1,48 @@
+package web_server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type BackupDbRequest struct {
+	grpcServerApi.BackupDbRequest
+}
+
+func NewBackupDbRequest() WebRequest {
+	return &BackupDbRequest{}
+}
+
+func (r *BackupDbRequest) GetName() string {
+	return "Backup Database " + r.Name
+}
+
+func (r *BackupDbRequest) Scopes() []string {
+	return []string{"db:write"}
+}
+
+func (r *BackupDbRequest) Resources() []string {
+	return []string{"db:" + r.Name}
+}
+
+func (r *BackupDbRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *BackupDbRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	response, err := h.DbManager.BackupDb(&r.BackupDbRequest)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, grpcServerApi.ErrInstanceOffline) {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, response)
+}
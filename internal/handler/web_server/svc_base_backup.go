@@ -0,0 +1,50 @@
+package web_server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type BaseBackupRequest struct {
+	grpcServerApi.BaseBackupRequest
+}
+
+func NewBaseBackupRequest() WebRequest {
+	return &BaseBackupRequest{}
+}
+
+func (r *BaseBackupRequest) GetName() string {
+	return fmt.Sprintf("Base Backup of Instance (%s)", r.InstanceName)
+}
+
+func (r *BaseBackupRequest) Scopes() []string {
+	return []string{"db:write"}
+}
+
+func (r *BaseBackupRequest) Resources() []string {
+	// The base backup contains all databases in the instance
+	return []string{"db"}
+}
+
+func (r *BaseBackupRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *BaseBackupRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	response, err := h.DbManager.BaseBackup(&r.BaseBackupRequest)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, grpcServerApi.ErrInstanceOffline) {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, response)
+}
@@ -0,0 +1,75 @@
+package web_server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type CancelJobRequest struct {
+	grpcServerApi.CancelJobRequest
+}
+
+func NewCancelJobRequest() WebRequest {
+	return &CancelJobRequest{}
+}
+
+func (r *CancelJobRequest) GetName() string {
+	return "Cancel Job " + r.JobId
+}
+
+func (r *CancelJobRequest) Scopes() []string {
+	return []string{"db:write"}
+}
+
+func (r *CancelJobRequest) Resources() []string {
+	return []string{"db:" + r.Name}
+}
+
+func (r *CancelJobRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *CancelJobRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	job, err := h.JobCatalog.GetJob(&grpcServerApi.GetJobRequest{
+		JobId: r.JobId,
+		Name:  r.Name,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, grpcServerApi.ErrJobNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	if job.InstanceName != r.InstanceName {
+		c.JSON(http.StatusNotFound, gin.H{"error": grpcServerApi.ErrJobNotFound.Error()})
+		return
+	}
+	switch job.Status {
+	case grpcServerApi.JobStatusCompleted, grpcServerApi.JobStatusFailed, grpcServerApi.JobStatusCancelled:
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "job is already " + job.Status,
+			"status": job.Status,
+		})
+		return
+	}
+
+	if err := h.DbManager.CancelJob(&r.CancelJobRequest); err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, grpcServerApi.ErrInstanceOffline) {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, grpcServerApi.DbJobResponse{
+		JobId:        r.JobId,
+		InstanceName: r.InstanceName,
+	})
+}
@@ -0,0 +1,51 @@
+package web_server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type CloneDbRequest struct {
+	grpcServerApi.CloneDbRequest
+}
+
+func NewCloneDbRequest() WebRequest {
+	return &CloneDbRequest{}
+}
+
+func (r *CloneDbRequest) GetName() string {
+	return "Clone Database " + r.SourceName + " to " + r.Name
+}
+
+func (r *CloneDbRequest) Scopes() []string {
+	return []string{"db:write"}
+}
+
+func (r *CloneDbRequest) Resources() []string {
+	// The clone contains the data of the source database
+	return []string{"db:" + r.Name, "db:" + r.SourceName}
+}
+
+func (r *CloneDbRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *CloneDbRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	response, err := h.DbManager.CloneDb(&r.CloneDbRequest)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, grpcServerApi.ErrInstanceOffline) {
+			code = http.StatusServiceUnavailable
+		} else if errors.Is(err, grpcServerApi.ErrBackupNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, response)
+}
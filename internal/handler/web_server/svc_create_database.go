@@ -42,7 +42,7 @@ func (r *CreateDbRequest) Process(c *gin.Context, handler WebHandler) {
 	webSource.State = sourceApi.SourceStateUnknown
 
 	if err := h.SourceHandler.AddDatabaseSource(webSource); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondSourceError(c, err)
 		return
 	}
 
@@ -0,0 +1,74 @@
+package web_server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/sourceApi"
+	"github.com/gin-gonic/gin"
+)
+
+type DeleteDbRequest struct {
+	Name string `uri:"name" json:"name" binding:"required,max=63,id"`
+}
+
+func NewDeleteDbRequest() WebRequest {
+	return &DeleteDbRequest{}
+}
+
+func (r *DeleteDbRequest) GetName() string {
+	return "Delete Database " + r.Name
+}
+
+func (r *DeleteDbRequest) Scopes() []string {
+	return []string{"db:write"}
+}
+
+func (r *DeleteDbRequest) Resources() []string {
+	return []string{"db:" + r.Name}
+}
+
+func (r *DeleteDbRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *DeleteDbRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	if _, err := getWebSource(h.SourceHandler, r.Name); err != nil {
+		respondSourceError(c, err)
+		return
+	}
+
+	// The database is idle after the grace period, and dropped by the agent later
+	if err := h.SourceHandler.MarkDatabaseSourceIdle(r.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"name": r.Name})
+}
+
+// getWebSource returns the source of the database,
+// only the sources created from web can be changed from web.
+func getWebSource(sourceGetter sourceApi.SourceGetter, name string) (*sourceApi.DatabaseSource, error) {
+	source := sourceGetter.GetSource(name)
+	if source == nil {
+		return nil, sourceApi.ErrSourceNotFound
+	}
+	if source.Type != sourceApi.WebSource {
+		return nil, sourceApi.ErrNotWebSource
+	}
+	return source, nil
+}
+
+func respondSourceError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	if errors.Is(err, sourceApi.ErrSourceNotFound) {
+		code = http.StatusNotFound
+	} else if errors.Is(err, sourceApi.ErrNotWebSource) || errors.Is(err, sourceApi.ErrSourceDeleting) {
+		code = http.StatusConflict
+	} else if errors.Is(err, sourceApi.ErrExpiresAtPast) {
+		code = http.StatusBadRequest
+	}
+	c.JSON(code, gin.H{"error": err.Error()})
+}
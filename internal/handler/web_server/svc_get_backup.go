@@ -0,0 +1,49 @@
+package web_server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type GetBackupRequest struct {
+	grpcServerApi.BackupRequest
+}
+
+func NewGetBackupRequest() WebRequest {
+	return &GetBackupRequest{}
+}
+
+func (r *GetBackupRequest) GetName() string {
+	return fmt.Sprintf("Get Backup (%s) in Instance (%s)", r.Path, r.InstanceName)
+}
+
+func (r *GetBackupRequest) Scopes() []string {
+	return []string{"db:read"}
+}
+
+func (r *GetBackupRequest) Resources() []string {
+	return []string{"db:" + r.Name}
+}
+
+func (r *GetBackupRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *GetBackupRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	backup, err := h.BackupCatalog.GetBackup(&r.BackupRequest)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, grpcServerApi.ErrBackupNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, backup)
+}
@@ -0,0 +1,49 @@
+package web_server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type GetDbRequest struct {
+	grpcServerApi.GetDbRequest
+}
+
+func NewGetDbRequest() WebRequest {
+	return &GetDbRequest{}
+}
+
+func (r *GetDbRequest) GetName() string {
+	return fmt.Sprintf("Get Db (%s) in Instance (%s)", r.Name, r.InstanceName)
+}
+
+func (r *GetDbRequest) Scopes() []string {
+	return []string{"db:read"}
+}
+
+func (r *GetDbRequest) Resources() []string {
+	return []string{"db:" + r.Name}
+}
+
+func (r *GetDbRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *GetDbRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	db, err := h.InstCatalog.GetDbDetail(&r.GetDbRequest)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, grpcServerApi.ErrDbNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, db)
+}
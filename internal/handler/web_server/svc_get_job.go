@@ -0,0 +1,54 @@
+package web_server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type GetJobRequest struct {
+	grpcServerApi.GetJobRequest
+}
+
+func NewGetJobRequest() WebRequest {
+	return &GetJobRequest{}
+}
+
+func (r *GetJobRequest) GetName() string {
+	return fmt.Sprintf("Get Job (%s) of Db (%s)", r.JobId, r.Name)
+}
+
+func (r *GetJobRequest) Scopes() []string {
+	return []string{"db:read"}
+}
+
+func (r *GetJobRequest) Resources() []string {
+	if r.Name == "" {
+		// The jobs of the instance (e.g. base backup) have no database,
+		// they can only be seen by the callers authorized to all the databases.
+		return []string{"db"}
+	}
+	return []string{"db:" + r.Name}
+}
+
+func (r *GetJobRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *GetJobRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	job, err := h.JobCatalog.GetJob(&r.GetJobRequest)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, grpcServerApi.ErrJobNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,51 @@
+package web_server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type GetRecoveryWindowRequest struct {
+	grpcServerApi.GetRecoveryWindowRequest
+}
+
+func NewGetRecoveryWindowRequest() WebRequest {
+	return &GetRecoveryWindowRequest{}
+}
+
+func (r *GetRecoveryWindowRequest) GetName() string {
+	return fmt.Sprintf("Get Recovery Window of Instance (%s)", r.InstanceName)
+}
+
+func (r *GetRecoveryWindowRequest) Scopes() []string {
+	return []string{"db:read"}
+}
+
+func (r *GetRecoveryWindowRequest) Resources() []string {
+	// The recovery window covers all databases in the instance
+	return []string{"db"}
+}
+
+func (r *GetRecoveryWindowRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *GetRecoveryWindowRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	window, err := h.InstCatalog.GetRecoveryWindow(&r.GetRecoveryWindowRequest)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, grpcServerApi.ErrInstanceNotFound) ||
+			errors.Is(err, grpcServerApi.ErrRecoveryWindowNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, window)
+}
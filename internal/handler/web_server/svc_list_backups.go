@@ -0,0 +1,44 @@
+package web_server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type ListBackupsRequest struct {
+	grpcServerApi.BackupFilter
+}
+
+func NewListBackupsRequest() WebRequest {
+	return &ListBackupsRequest{}
+}
+
+func (r *ListBackupsRequest) GetName() string {
+	return fmt.Sprintf("List Backups of Db (%s) in Instance (%s)", r.Name, r.InstanceName)
+}
+
+func (r *ListBackupsRequest) Scopes() []string {
+	return []string{"db:read"}
+}
+
+func (r *ListBackupsRequest) Resources() []string {
+	// Listing the backups of all databases requires the permission of all databases
+	if r.Name == "" {
+		return []string{"db"}
+	}
+	return []string{"db:" + r.Name}
+}
+
+func (r *ListBackupsRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *ListBackupsRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	backups := h.BackupCatalog.ListBackups(&r.BackupFilter)
+	c.JSON(http.StatusOK, gin.H{"backups": backups})
+}
@@ -0,0 +1,50 @@
+package web_server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type ListDbsRequest struct {
+	grpcServerApi.ListDbsRequest
+}
+
+func NewListDbsRequest() WebRequest {
+	return &ListDbsRequest{}
+}
+
+func (r *ListDbsRequest) GetName() string {
+	return fmt.Sprintf("List Dbs in Instance (%s)", r.InstanceName)
+}
+
+func (r *ListDbsRequest) Scopes() []string {
+	return []string{"db:read"}
+}
+
+func (r *ListDbsRequest) Resources() []string {
+	// Listing all databases in the instance requires the permission of all databases
+	return []string{"db"}
+}
+
+func (r *ListDbsRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *ListDbsRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	dbs, err := h.InstCatalog.ListDbs(&r.ListDbsRequest)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, grpcServerApi.ErrInstanceNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"dbs": dbs})
+}
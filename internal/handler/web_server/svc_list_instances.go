@@ -0,0 +1,37 @@
+package web_server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ListInstancesRequest struct{}
+
+func NewListInstancesRequest() WebRequest {
+	return &ListInstancesRequest{}
+}
+
+func (r *ListInstancesRequest) GetName() string {
+	return "List Instances"
+}
+
+func (r *ListInstancesRequest) Scopes() []string {
+	return []string{"db:read"}
+}
+
+func (r *ListInstancesRequest) Resources() []string {
+	// The instances host all databases
+	return []string{"db"}
+}
+
+func (r *ListInstancesRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *ListInstancesRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	instances := h.InstCatalog.ListInstances()
+	c.JSON(http.StatusOK, gin.H{"instances": instances})
+}
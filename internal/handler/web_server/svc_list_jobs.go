@@ -0,0 +1,44 @@
+package web_server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type ListJobsRequest struct {
+	grpcServerApi.ListJobsRequest
+}
+
+func NewListJobsRequest() WebRequest {
+	return &ListJobsRequest{}
+}
+
+func (r *ListJobsRequest) GetName() string {
+	return fmt.Sprintf("List Jobs of Db (%s)", r.Name)
+}
+
+func (r *ListJobsRequest) Scopes() []string {
+	return []string{"db:read"}
+}
+
+func (r *ListJobsRequest) Resources() []string {
+	return []string{"db:" + r.Name}
+}
+
+func (r *ListJobsRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *ListJobsRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	jobs, err := h.JobCatalog.ListJobs(&r.ListJobsRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs})
+}
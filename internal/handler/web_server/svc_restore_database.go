@@ -0,0 +1,50 @@
+package web_server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type RestoreDbRequest struct {
+	grpcServerApi.RestoreDbRequest
+}
+
+func NewRestoreDbRequest() WebRequest {
+	return &RestoreDbRequest{}
+}
+
+func (r *RestoreDbRequest) GetName() string {
+	return "Restore Database " + r.Name
+}
+
+func (r *RestoreDbRequest) Scopes() []string {
+	return []string{"db:write"}
+}
+
+func (r *RestoreDbRequest) Resources() []string {
+	return []string{"db:" + r.Name}
+}
+
+func (r *RestoreDbRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *RestoreDbRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	response, err := h.DbManager.RestoreDb(&r.RestoreDbRequest)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, grpcServerApi.ErrInstanceOffline) {
+			code = http.StatusServiceUnavailable
+		} else if errors.Is(err, grpcServerApi.ErrBackupNotFound) || errors.Is(err, grpcServerApi.ErrDbNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, response)
+}
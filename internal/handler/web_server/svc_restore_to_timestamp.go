@@ -0,0 +1,62 @@
+package web_server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type RestoreToTimestampRequest struct {
+	grpcServerApi.RestoreToTimestampRequest
+}
+
+func NewRestoreToTimestampRequest() WebRequest {
+	return &RestoreToTimestampRequest{}
+}
+
+func (r *RestoreToTimestampRequest) GetName() string {
+	return fmt.Sprintf("Restore Instance (%s) to Timestamp", r.InstanceName)
+}
+
+func (r *RestoreToTimestampRequest) Scopes() []string {
+	return []string{"db:write"}
+}
+
+func (r *RestoreToTimestampRequest) Resources() []string {
+	// The recovery restores all databases in the instance
+	return []string{"db"}
+}
+
+func (r *RestoreToTimestampRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *RestoreToTimestampRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	if window, err := h.InstCatalog.GetRecoveryWindow(&grpcServerApi.GetRecoveryWindowRequest{
+		InstanceName: r.InstanceName,
+	}); err == nil && window.Available &&
+		(r.TargetTime.Before(*window.StartTime) || r.TargetTime.After(*window.EndTime)) {
+		// The agent checks the window again, it may have moved since it was reported
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":           "target time is out of the recovery window",
+			"recovery_window": window,
+		})
+		return
+	}
+
+	response, err := h.DbManager.RestoreToTimestamp(&r.RestoreToTimestampRequest)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, grpcServerApi.ErrInstanceOffline) {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, response)
+}
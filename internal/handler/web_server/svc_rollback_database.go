@@ -0,0 +1,52 @@
+package web_server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type RollbackDbRequest struct {
+	grpcServerApi.RollbackDbRequest
+}
+
+func NewRollbackDbRequest() WebRequest {
+	return &RollbackDbRequest{}
+}
+
+func (r *RollbackDbRequest) GetName() string {
+	return "Rollback Database " + r.Name
+}
+
+func (r *RollbackDbRequest) Scopes() []string {
+	return []string{"db:write"}
+}
+
+func (r *RollbackDbRequest) Resources() []string {
+	return []string{"db:" + r.Name}
+}
+
+func (r *RollbackDbRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *RollbackDbRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	if err := h.DbManager.RollbackDb(&r.RollbackDbRequest); err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, grpcServerApi.ErrInstanceOffline) {
+			code = http.StatusServiceUnavailable
+		} else if errors.Is(err, grpcServerApi.ErrDbNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"name":          r.Name,
+		"instance_name": r.InstanceName,
+	})
+}
@@ -0,0 +1,93 @@
+package web_server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/a-light-win/pg-helper/internal/interface/sourceApi"
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateDbRequest moves the database to another pg instance,
+// the fields that are empty keep their current values.
+type UpdateDbRequest struct {
+	Name string `uri:"name" json:"-" binding:"required,max=63,id"`
+
+	InstanceName string `json:"instance_name" binding:"omitempty,max=63,iname"`
+	// It defaults to the current instance if the instance is changed
+	MigrateFrom     string `json:"migrate_from" binding:"omitempty,max=63,iname"`
+	MigrateStrategy string `json:"migrate_strategy" binding:"omitempty,oneof=dump logical"`
+}
+
+func NewUpdateDbRequest() WebRequest {
+	return &UpdateDbRequest{}
+}
+
+func (r *UpdateDbRequest) GetName() string {
+	return "Update Database " + r.Name
+}
+
+func (r *UpdateDbRequest) Scopes() []string {
+	return []string{"db:write"}
+}
+
+func (r *UpdateDbRequest) Resources() []string {
+	return []string{"db:" + r.Name}
+}
+
+func (r *UpdateDbRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *UpdateDbRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	oldSource, err := getWebSource(h.SourceHandler, r.Name)
+	if err != nil {
+		respondSourceError(c, err)
+		return
+	}
+	if oldSource.ExpectState == sourceApi.SourceStateIdle {
+		respondSourceError(c, sourceApi.ErrSourceDeleting)
+		return
+	}
+
+	// The source is replaced, the old one may be still used by the scheduled jobs
+	dbRequest := *oldSource.DatabaseRequest
+	instanceChanged := r.InstanceName != "" && r.InstanceName != dbRequest.InstanceName
+	if r.MigrateFrom != "" {
+		if !instanceChanged {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "migrate_from requires instance_name to be changed"})
+			return
+		}
+		if r.MigrateFrom == r.InstanceName {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "migrate_from must be different from instance_name"})
+			return
+		}
+	}
+
+	if instanceChanged {
+		dbRequest.MigrateFrom = dbRequest.InstanceName
+		dbRequest.InstanceName = r.InstanceName
+	}
+	if r.MigrateFrom != "" {
+		dbRequest.MigrateFrom = r.MigrateFrom
+	}
+	if r.MigrateStrategy != "" {
+		dbRequest.MigrateStrategy = r.MigrateStrategy
+	}
+
+	webSource := &sourceApi.DatabaseSource{
+		DatabaseRequest: &dbRequest,
+		Type:            sourceApi.WebSource,
+	}
+	webSource.State = sourceApi.SourceStateUnknown
+
+	if err := h.SourceHandler.AddDatabaseSource(webSource); err != nil {
+		respondSourceError(c, err)
+		return
+	}
+
+	ready := h.ReadyWaiter.WaitReady(dbRequest.InstanceName, dbRequest.Name, 5*time.Second)
+	c.JSON(http.StatusOK, gin.H{"ready": ready})
+}
@@ -0,0 +1,50 @@
+package web_server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/gin-gonic/gin"
+)
+
+type VerifyBackupRequest struct {
+	grpcServerApi.VerifyBackupRequest
+}
+
+func NewVerifyBackupRequest() WebRequest {
+	return &VerifyBackupRequest{}
+}
+
+func (r *VerifyBackupRequest) GetName() string {
+	return "Verify Backup of Database " + r.Name
+}
+
+func (r *VerifyBackupRequest) Scopes() []string {
+	return []string{"db:write"}
+}
+
+func (r *VerifyBackupRequest) Resources() []string {
+	return []string{"db:" + r.Name}
+}
+
+func (r *VerifyBackupRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *VerifyBackupRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*DbHandler)
+
+	response, err := h.DbManager.VerifyBackup(&r.VerifyBackupRequest)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, grpcServerApi.ErrInstanceOffline) {
+			code = http.StatusServiceUnavailable
+		} else if errors.Is(err, grpcServerApi.ErrDbNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, response)
+}
@@ -0,0 +1,154 @@
+package web_server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/a-light-win/pg-helper/pkg/auth"
+	ginAuth "github.com/a-light-win/pg-helper/pkg/auth/gin"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// The interval to send a comment to keep the connection alive through the proxies
+const watchKeepAliveInterval = 30 * time.Second
+
+type WatchDbsRequest struct {
+	Name         string `form:"name" binding:"omitempty,max=63,id"`
+	InstanceName string `form:"instance_name" binding:"omitempty,max=63,iname"`
+}
+
+func NewWatchDbsRequest() WebRequest {
+	return &WatchDbsRequest{}
+}
+
+func (r *WatchDbsRequest) GetName() string {
+	return fmt.Sprintf("Watch Dbs (%s) in Instance (%s)", r.Name, r.InstanceName)
+}
+
+func (r *WatchDbsRequest) Scopes() []string {
+	return []string{"db:read"}
+}
+
+func (r *WatchDbsRequest) Resources() []string {
+	if r.Name != "" {
+		return []string{"db:" + r.Name}
+	}
+	// Only the events of the authorized databases are sent,
+	// they are filtered one by one when streaming.
+	return []string{}
+}
+
+func (r *WatchDbsRequest) AuthRequired() bool {
+	return true
+}
+
+func (r *WatchDbsRequest) Process(c *gin.Context, handler WebHandler) {
+	h := handler.(*WatchHandler)
+
+	authInfo, ok := ginAuth.FetchAuthInfo(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	seq, resumed := h.DbEvents.ParseEventId(c.GetHeader("Last-Event-ID"))
+	if !resumed {
+		// Send the current status first, then the changes after it
+		seq = h.DbEvents.LastSeq()
+		r.sendSnapshot(c, h, authInfo, seq)
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(watchKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		events, changed := h.DbEvents.Since(seq)
+		for _, event := range events {
+			seq = event.Seq
+			if !r.visible(authInfo, event) {
+				continue
+			}
+			r.send(c, h.DbEvents.EventId(event.Seq), event.Event, event.Data())
+		}
+		c.Writer.Flush()
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			if _, err := c.Writer.WriteString(": keepalive\n\n"); err != nil {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
+		case <-h.DbEvents.Closed():
+			return
+		}
+	}
+}
+
+func (r *WatchDbsRequest) sendSnapshot(c *gin.Context, h *WatchHandler, authInfo *auth.AuthInfo, seq uint64) {
+	id := h.DbEvents.EventId(seq)
+	for _, instance := range h.InstCatalog.ListInstances() {
+		if r.InstanceName != "" && instance.Name != r.InstanceName {
+			continue
+		}
+		if r.Name == "" && authInfo.ValidateResource("db") {
+			r.send(c, id, DbEventInstance, instance)
+		}
+
+		dbs, err := h.InstCatalog.ListDbs(&grpcServerApi.ListDbsRequest{InstanceName: instance.Name})
+		if err != nil {
+			continue
+		}
+		for _, db := range dbs {
+			if r.visible(authInfo, &DbEvent{Event: DbEventDb, Db: db}) {
+				r.send(c, id, DbEventDb, db)
+			}
+		}
+	}
+}
+
+// visible returns true if the event matches the request and the caller is authorized to see it
+func (r *WatchDbsRequest) visible(authInfo *auth.AuthInfo, event *DbEvent) bool {
+	switch event.Event {
+	case DbEventDb:
+		if r.Name != "" && event.Db.Name != r.Name {
+			return false
+		}
+		if r.InstanceName != "" && event.Db.InstanceName != r.InstanceName {
+			return false
+		}
+		return authInfo.ValidateResource("db:" + event.Db.Name)
+	case DbEventInstance:
+		// The instance events are only sent to the callers who can see all databases
+		if r.Name != "" {
+			return false
+		}
+		if r.InstanceName != "" && event.Instance.Name != r.InstanceName {
+			return false
+		}
+		return authInfo.ValidateResource("db")
+	}
+	return false
+}
+
+func (r *WatchDbsRequest) send(c *gin.Context, id string, event string, data interface{}) {
+	err := sse.Encode(c.Writer, sse.Event{
+		Id:    id,
+		Event: event,
+		Data:  data,
+	})
+	if err != nil {
+		log.Debug().Err(err).Str("Name", r.GetName()).Msg("Failed to send the event")
+	}
+}
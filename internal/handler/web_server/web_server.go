@@ -26,6 +26,12 @@ type WebServer struct {
 
 	sourceHandler sourceApi.SourceHandler
 	dbReadyWaiter grpcServerApi.DbReadyWaiter
+	backupCatalog grpcServerApi.BackupCatalog
+	dbManager     grpcServerApi.DbManager
+	instCatalog   grpcServerApi.InstanceCatalog
+	jobCatalog    grpcServerApi.JobCatalog
+
+	dbEvents *DbEventLog
 }
 
 func NewWebServer(config *config.WebConfig) *WebServer {
@@ -90,6 +96,15 @@ func (w *WebServer) Init(setter server.GlobalSetter) error {
 func (w *WebServer) PostInit(getter server.GlobalGetter) error {
 	w.sourceHandler = getter.Get(constants.ServerKeySourceHandler).(sourceApi.SourceHandler)
 	w.dbReadyWaiter = getter.Get(constants.ServerKeyDbReadyWaiter).(grpcServerApi.DbReadyWaiter)
+	w.backupCatalog = getter.Get(constants.ServerKeyBackupCatalog).(grpcServerApi.BackupCatalog)
+	w.dbManager = getter.Get(constants.ServerKeyDbManager).(grpcServerApi.DbManager)
+	w.instCatalog = getter.Get(constants.ServerKeyInstanceCatalog).(grpcServerApi.InstanceCatalog)
+	w.jobCatalog = getter.Get(constants.ServerKeyJobCatalog).(grpcServerApi.JobCatalog)
+
+	w.dbEvents = NewDbEventLog()
+	w.dbEvents.Subscribe(w.dbManager)
+	// The watchers never finish by themselves, stop them to not block the shutdown
+	w.Server.RegisterOnShutdown(w.dbEvents.Close)
 
 	w.registerRoutes()
 	return nil
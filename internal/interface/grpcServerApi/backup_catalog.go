@@ -0,0 +1,66 @@
+package grpcServerApi
+
+import (
+	"time"
+
+	"github.com/a-light-win/pg-helper/pkg/proto"
+)
+
+type BackupFilter struct {
+	// List the backups of the database in all instances if InstanceName is empty
+	Name string `form:"name" json:"name" binding:"max=63,id"`
+	// List the backups of all databases in the instance if Name is empty
+	InstanceName string `form:"instance_name" json:"instance_name" binding:"max=63,iname"`
+}
+
+type BackupRequest struct {
+	// The database that the backup belongs to
+	Name         string `form:"name" json:"name" binding:"required,max=63,id"`
+	InstanceName string `form:"instance_name" json:"instance_name" binding:"required,max=63,iname"`
+	Path         string `form:"path" json:"path" binding:"required,max=256"`
+}
+
+type BackupResponse struct {
+	Name         string `json:"name"`
+	InstanceName string `json:"instance_name"`
+	// The path can be used as the backup_path to create or restore a database
+	Path           string     `json:"path"`
+	Format         string     `json:"format"`
+	Compression    string     `json:"compression"`
+	Encrypted      bool       `json:"encrypted"`
+	Size           int64      `json:"size"`
+	Sha256         string     `json:"sha256"`
+	SourceInstance string     `json:"source_instance"`
+	PgMajor        int32      `json:"pg_major"`
+	CreatedAt      time.Time  `json:"created_at"`
+	VerifiedAt     *time.Time `json:"verified_at,omitempty"`
+	VerifyPassed   bool       `json:"verify_passed"`
+}
+
+func NewBackupResponse(backup *proto.Backup) *BackupResponse {
+	response := &BackupResponse{
+		Name:           backup.Name,
+		InstanceName:   backup.InstanceName,
+		Path:           backup.Path,
+		Format:         backup.Format,
+		Compression:    backup.Compression,
+		Encrypted:      backup.Encrypted,
+		Size:           backup.Size,
+		Sha256:         backup.Sha256,
+		SourceInstance: backup.SourceInstance,
+		PgMajor:        backup.PgMajor,
+		CreatedAt:      backup.CreatedAt.AsTime(),
+		VerifyPassed:   backup.VerifyPassed,
+	}
+	if backup.VerifiedAt != nil {
+		verifiedAt := backup.VerifiedAt.AsTime()
+		response.VerifiedAt = &verifiedAt
+	}
+	return response
+}
+
+type BackupCatalog interface {
+	// ListBackups returns the backups matched the filter, newest first
+	ListBackups(filter *BackupFilter) []*BackupResponse
+	GetBackup(request *BackupRequest) (*BackupResponse, error)
+}
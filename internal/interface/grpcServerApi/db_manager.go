@@ -30,14 +30,105 @@ type CreateDbRequest struct {
 	Reason      string `json:"reason" binding:"max=1024"`
 	MigrateFrom string `json:"migrate_from" binding:"max=63,iname"`
 	BackupPath  string `json:"backup_path" binding:"max=256"`
+	// How to migrate the database from MigrateFrom, one of dump, logical
+	MigrateStrategy string `json:"migrate_strategy" binding:"omitempty,oneof=dump logical"`
 }
 
 type MigrateOutDbRequest struct {
-	Name         string    `json:"name" binding:"required,max=63,id"`
-	InstanceName string    `json:"instance_name" binding:"required,max=63,iname"`
+	Name         string `json:"name" binding:"required,max=63,id"`
+	InstanceName string `json:"instance_name" binding:"required,max=63,iname"`
+	Reason       string `json:"reason" binding:"max=1024"`
+	MigrateTo    string `json:"migrate_to" binding:"required,max=63,iname"`
+	// The idle database is dropped after ExpireAt, the agent decides when to drop it if it is zero.
+	ExpireAt time.Time `json:"-"`
+}
+
+type IdleDbRequest struct {
+	Name         string `json:"name" binding:"required,max=63,id"`
+	InstanceName string `json:"instance_name" binding:"required,max=63,iname"`
+	Reason       string `json:"reason" binding:"max=1024"`
+	// The idle database is dropped after ExpireAt,
+	// the agent decides when to drop it if it is zero.
+	ExpireAt time.Time `json:"-"`
+}
+
+type RollbackDbRequest struct {
+	Name string `json:"name" binding:"required,max=63,id"`
+	// The instance that the database is rolled back to
+	InstanceName string `json:"instance_name" binding:"required,max=63,iname"`
+	Reason       string `json:"reason" binding:"max=1024"`
+}
+
+type VerifyBackupRequest struct {
+	Name         string `json:"name" binding:"required,max=63,id"`
+	InstanceName string `json:"instance_name" binding:"required,max=63,iname"`
+	Reason       string `json:"reason" binding:"max=1024"`
+	// The latest backup is verified if empty
+	BackupPath string `json:"backup_path" binding:"max=256"`
+	// SQL queries that return a single boolean
+	Assertions []string `json:"assertions" binding:"max=32,dive,max=4096"`
+}
+
+type BackupDbRequest struct {
+	Name string `json:"name" binding:"required,max=63,id"`
+	// The instance that the database is ready to use in, it is looked up by the name if empty
+	InstanceName string `json:"instance_name" binding:"max=63,iname"`
+	Reason       string `json:"reason" binding:"max=1024"`
+}
+
+type RestoreDbRequest struct {
+	Name string `json:"name" binding:"required,max=63,id"`
+	// The instance that the database is ready to use in, it is looked up by the name if empty
+	InstanceName string `json:"instance_name" binding:"max=63,iname"`
+	Reason       string `json:"reason" binding:"max=1024"`
+	// The backup to restore from, it can be found in the backup catalog
+	BackupPath string `json:"backup_path" binding:"required,max=256"`
+}
+
+type CloneDbRequest struct {
+	// The name of the new database
+	Name string `json:"name" binding:"required,max=63,id"`
+	// The instance that the source database is ready to use in, it is looked up by the source name if empty
+	InstanceName string `json:"instance_name" binding:"max=63,iname"`
+	SourceName   string `json:"source_name" binding:"required,max=63,id"`
+	Owner        string `json:"owner" binding:"required,max=63,id"`
+	Password     string `json:"password" binding:"required,min=8,max=256"`
+	Reason       string `json:"reason" binding:"max=1024"`
+	// The backup of the source database, the live source database is backed up if empty
+	BackupPath string `json:"backup_path" binding:"max=256"`
+	// The clone is dropped after it is expired, it never expires if not set
+	ExpiredAt *time.Time `json:"expired_at"`
+}
+
+type CancelJobRequest struct {
+	JobId string `json:"job_id" binding:"required,uuid"`
+	// The database that the job belongs to
+	Name string `json:"name" binding:"required,max=63,id"`
+	// The instance that the job runs in, it is returned when the job is started
+	InstanceName string `json:"instance_name" binding:"required,max=63,iname"`
+	Reason       string `json:"reason" binding:"max=1024"`
+}
+
+// DbJobResponse is returned when a job is sent to the agent,
+// the result can be polled by the job id.
+type DbJobResponse struct {
+	JobId        string `json:"job_id"`
+	InstanceName string `json:"instance_name"`
+}
+
+type BaseBackupRequest struct {
+	InstanceName string `uri:"instance_name" json:"instance_name" binding:"required,max=63,iname"`
+	Reason       string `json:"reason" binding:"max=1024"`
+}
+
+type RestoreToTimestampRequest struct {
+	// The instance that the recovery is prepared on
+	InstanceName string    `uri:"instance_name" json:"instance_name" binding:"required,max=63,iname"`
 	Reason       string    `json:"reason" binding:"max=1024"`
-	MigrateTo    string    `json:"migrate_to" binding:"required,max=63,iname"`
-	ExpireAt     time.Time `json:"-"`
+	TargetTime   time.Time `json:"target_time" binding:"required"`
+	// The new data directory under the restore root of the agent,
+	// a relative path is relative to the restore root.
+	DataDir string `json:"data_dir" binding:"required,max=1024"`
 }
 
 type DbStatusResponse struct {
@@ -72,6 +163,15 @@ func (status *DbStatusResponse) IsMigrateOutReady(name string, instanceName stri
 type DbManager interface {
 	GetDbStatus(request *DbRequest) (*DbStatusResponse, error)
 	CreateDb(request *CreateDbRequest) error
+	IdleDb(request *IdleDbRequest) error
+	RollbackDb(request *RollbackDbRequest) error
+	VerifyBackup(request *VerifyBackupRequest) (*DbJobResponse, error)
+	BackupDb(request *BackupDbRequest) (*DbJobResponse, error)
+	RestoreDb(request *RestoreDbRequest) (*DbJobResponse, error)
+	CloneDb(request *CloneDbRequest) (*DbJobResponse, error)
+	CancelJob(request *CancelJobRequest) error
+	BaseBackup(request *BaseBackupRequest) (*DbJobResponse, error)
+	RestoreToTimestamp(request *RestoreToTimestampRequest) (*DbJobResponse, error)
 
 	SubscribeDbStatus
 	SubscribeInstanceStatus
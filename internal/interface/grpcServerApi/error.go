@@ -2,4 +2,12 @@ package grpcServerApi
 
 import "errors"
 
-var ErrInstanceOffline error = errors.New("instance offline")
+var (
+	ErrInstanceOffline  error = errors.New("instance offline")
+	ErrInstanceNotFound error = errors.New("instance not found")
+	ErrDbNotFound       error = errors.New("database not found")
+	ErrBackupNotFound   error = errors.New("backup not found")
+	ErrJobNotFound      error = errors.New("job not found")
+	// The agent has not reported the recovery window, e.g. the point-in-time recovery is disabled
+	ErrRecoveryWindowNotFound error = errors.New("recovery window not found")
+)
@@ -0,0 +1,115 @@
+package grpcServerApi
+
+import (
+	"time"
+
+	"github.com/a-light-win/pg-helper/pkg/proto"
+)
+
+type InstanceResponse struct {
+	Name    string `json:"name"`
+	Version int32  `json:"version"`
+	Online  bool   `json:"online"`
+}
+
+type ListDbsRequest struct {
+	InstanceName string `uri:"instance_name" json:"instance_name" binding:"required,max=63,iname"`
+}
+
+type GetRecoveryWindowRequest struct {
+	InstanceName string `uri:"instance_name" json:"instance_name" binding:"required,max=63,iname"`
+}
+
+type GetDbRequest struct {
+	Name string `uri:"name" json:"name" binding:"required,max=63,id"`
+	// The instance that the database is ready to use in is preferred if empty
+	InstanceName string `form:"instance_name" json:"instance_name" binding:"max=63,iname"`
+}
+
+type DbResponse struct {
+	DbStatusResponse
+
+	Owner       string     `json:"owner"`
+	MigrateFrom string     `json:"migrate_from"`
+	MigrateTo   string     `json:"migrate_to"`
+	LastJobId   string     `json:"last_job_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiredAt   *time.Time `json:"expired_at,omitempty"`
+
+	// The result of the latest backup verification, it is kept in memory only
+	LastVerification *VerificationResponse `json:"last_verification,omitempty"`
+}
+
+type VerificationResponse struct {
+	JobId            string    `json:"job_id"`
+	BackupPath       string    `json:"backup_path"`
+	Passed           bool      `json:"passed"`
+	TableCount       int64     `json:"table_count"`
+	FailedAssertions []string  `json:"failed_assertions"`
+	ErrorMsg         string    `json:"error_msg"`
+	VerifiedAt       time.Time `json:"verified_at"`
+}
+
+// RecoveryWindowResponse is the time range that the instance can be recovered to
+// by RestoreToTimestamp, it is reported by the agent.
+type RecoveryWindowResponse struct {
+	InstanceName string     `json:"instance_name"`
+	Available    bool       `json:"available"`
+	StartTime    *time.Time `json:"start_time,omitempty"`
+	EndTime      *time.Time `json:"end_time,omitempty"`
+	StartWalFile string     `json:"start_wal_file"`
+	EndWalFile   string     `json:"end_wal_file"`
+
+	BaseBackups []*BaseBackupResponse `json:"base_backups"`
+}
+
+type BaseBackupResponse struct {
+	Path         string    `json:"path"`
+	Timeline     int32     `json:"timeline"`
+	StartWalFile string    `json:"start_wal_file"`
+	StopWalFile  string    `json:"stop_wal_file"`
+	Size         int64     `json:"size"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+}
+
+func NewRecoveryWindowResponse(window *proto.RecoveryWindow) *RecoveryWindowResponse {
+	response := &RecoveryWindowResponse{
+		InstanceName: window.InstanceName,
+		Available:    window.Available,
+		StartWalFile: window.StartWalFile,
+		EndWalFile:   window.EndWalFile,
+		BaseBackups:  make([]*BaseBackupResponse, 0, len(window.BaseBackups)),
+	}
+	if window.StartTime != nil {
+		startTime := window.StartTime.AsTime()
+		response.StartTime = &startTime
+	}
+	if window.EndTime != nil {
+		endTime := window.EndTime.AsTime()
+		response.EndTime = &endTime
+	}
+	for _, backup := range window.BaseBackups {
+		response.BaseBackups = append(response.BaseBackups, &BaseBackupResponse{
+			Path:         backup.Path,
+			Timeline:     backup.Timeline,
+			StartWalFile: backup.StartWalFile,
+			StopWalFile:  backup.StopWalFile,
+			Size:         backup.Size,
+			StartedAt:    backup.StartedAt.AsTime(),
+			FinishedAt:   backup.FinishedAt.AsTime(),
+		})
+	}
+	return response
+}
+
+type InstanceCatalog interface {
+	// ListInstances returns all the registered instances, sorted by name
+	ListInstances() []*InstanceResponse
+	// ListDbs returns the databases in the instance sorted by name,
+	// the databases are unknown until the instance is online.
+	ListDbs(request *ListDbsRequest) ([]*DbStatusResponse, error)
+	GetDbDetail(request *GetDbRequest) (*DbResponse, error)
+	// GetRecoveryWindow returns the latest recovery window reported by the agent of the instance
+	GetRecoveryWindow(request *GetRecoveryWindowRequest) (*RecoveryWindowResponse, error)
+}
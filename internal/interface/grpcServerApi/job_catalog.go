@@ -0,0 +1,91 @@
+package grpcServerApi
+
+import (
+	"time"
+)
+
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+type GetJobRequest struct {
+	JobId string `uri:"id" json:"job_id" binding:"required,uuid"`
+	// The database that the job belongs to,
+	// it is empty for the jobs of the instance (e.g. base backup)
+	Name string `form:"name" json:"name" binding:"omitempty,max=63,id"`
+}
+
+type ListJobsRequest struct {
+	Name string `uri:"name" json:"name" binding:"required,max=63,id"`
+	// The max count of the jobs to return, newest first
+	Limit int32 `form:"limit" json:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+type JobResponse struct {
+	JobId        string `json:"job_id"`
+	InstanceName string `json:"instance_name"`
+	// The database that the job works on, it is empty if the job is not for a database
+	DbName string `json:"db_name"`
+	// The job sent by the server, e.g. create_database,
+	// it is empty if the job is started by the agent itself, e.g. the daily backup.
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+	// One of pending, running, completed, failed, cancelled
+	Status    string          `json:"status"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Tasks     []*TaskResponse `json:"tasks"`
+}
+
+type TaskResponse struct {
+	TaskId string `json:"task_id"`
+	DbName string `json:"db_name"`
+	// The action of the task, e.g. create, backup, restore
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+	// One of pending, running, cancelling, completed, failed, cancelled
+	Status    string    `json:"status"`
+	ErrReason string    `json:"err_reason"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// JobStatusOf returns the status of the job by its tasks
+func JobStatusOf(tasks []*TaskResponse) string {
+	pending, running, failed, cancelled := 0, 0, 0, 0
+	for _, task := range tasks {
+		switch task.Status {
+		case "pending":
+			pending++
+		case "running", "cancelling":
+			running++
+		case "failed":
+			failed++
+		case "cancelled":
+			cancelled++
+		}
+	}
+
+	switch {
+	case len(tasks) == 0 || pending == len(tasks):
+		return JobStatusPending
+	case running > 0 || pending > 0:
+		return JobStatusRunning
+	case failed > 0:
+		return JobStatusFailed
+	case cancelled > 0:
+		return JobStatusCancelled
+	default:
+		return JobStatusCompleted
+	}
+}
+
+type JobCatalog interface {
+	GetJob(request *GetJobRequest) (*JobResponse, error)
+	// ListJobs returns the job history of the database, newest first
+	ListJobs(request *ListJobsRequest) ([]*JobResponse, error)
+}
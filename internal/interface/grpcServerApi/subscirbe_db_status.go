@@ -20,4 +20,8 @@ type SubscribeDbStatus interface {
 	// - Idle
 	// - DropCompleted
 	SubscribeDbStatus(callback SubscribeDbStatusFunc)
+
+	// Subscribe to all the stage and status changes of the databases,
+	// including the ones that are still in progress
+	SubscribeDbTransitions(callback SubscribeDbStatusFunc)
 }
@@ -13,12 +13,18 @@ import (
 type DatabaseRequest struct {
 	Name         string `yaml:"name" json:"name" validate:"required,max=63,id" binding:"required,max=63,id" help:"Name of the database"`
 	Owner        string `yaml:"owner" json:"owner" validate:"required,max=63,id" binding:"required,max=63,id" help:"Owner of the database"`
-	PasswordFile string `yaml:"password_file" json:"-" validate:"required,file" binding:"-" help:"Path to the password file of the database owner"`
+	PasswordFile string `yaml:"password_file" json:"-" validate:"required_without=Password,omitempty,file" binding:"-" help:"Path to the password file of the database owner"`
 	Password     string `yaml:"-" json:"password" binding:"required,min=8,max=256" help:"Password of the database owner"`
 
 	InstanceName string `yaml:"instance_name" json:"instance_name" validate:"required,max=63,iname" binding:"required,max=63,iname" help:"Name of the pg instance"`
 	MigrateFrom  string `yaml:"migrate_from" json:"migrate_from" validate:"omitempty,max=63,iname" binding:"omitempty,max=63,iname" help:"Migrate database from another pg instance"`
 	BackupPath   string `yaml:"backup_path" json:"-" validate:"omitempty,file" binding:"-" help:"Path to the backup file"`
+	// dump: restore a backup of the old database, the application must stop writing during the migration
+	// logical: replicate the old database by logical replication, the application only stops writing during the cutover
+	MigrateStrategy string `yaml:"migrate_strategy" json:"migrate_strategy" validate:"omitempty,oneof=dump logical" binding:"omitempty,oneof=dump logical" help:"How to migrate the database from another pg instance, one of dump, logical"`
+
+	Ttl       string     `yaml:"ttl" json:"ttl" validate:"omitempty,ttl" binding:"omitempty,ttl" help:"Drop the database after the duration since the source is added, e.g. 72h"`
+	ExpiresAt *time.Time `yaml:"expires_at" json:"expires_at" help:"Drop the database after the time, it takes precedence over ttl"`
 }
 
 type DatabaseSource struct {
@@ -37,6 +43,12 @@ type DatabaseSourceStatus struct {
 	RetryDelay int `yaml:"-"`
 	RetryTimes int `yaml:"-"`
 
+	// The time that the source is first added, the ttl is counted from it,
+	// it is kept when the source is changed or added again.
+	AddedAt time.Time `yaml:"-"`
+	// The time to drop the ephemeral database, it is zero if the database never expires
+	ExpireAt time.Time `yaml:"-"`
+
 	LastErrorMsg    string    `yaml:"-"`
 	LastScheduledAt time.Time `yaml:"-"`
 	NextScheduleAt  time.Time `yaml:"-"`
@@ -64,7 +76,33 @@ const (
 func (s *DatabaseRequest) IsConfigChanged(newSource *DatabaseRequest) bool {
 	return s.InstanceName != newSource.InstanceName ||
 		s.MigrateFrom != newSource.MigrateFrom ||
-		s.BackupPath != newSource.BackupPath
+		s.BackupPath != newSource.BackupPath ||
+		s.MigrateStrategy != newSource.MigrateStrategy ||
+		s.Ttl != newSource.Ttl ||
+		!sameTime(s.ExpiresAt, newSource.ExpiresAt)
+}
+
+func sameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// ExpireTime returns the time to drop the database,
+// the ttl is counted from addedAt. It returns zero if the database never expires.
+func (s *DatabaseRequest) ExpireTime(addedAt time.Time) time.Time {
+	if s.ExpiresAt != nil {
+		return *s.ExpiresAt
+	}
+	if s.Ttl == "" {
+		return time.Time{}
+	}
+	ttl, err := time.ParseDuration(s.Ttl)
+	if err != nil {
+		return time.Time{}
+	}
+	return addedAt.Add(ttl)
 }
 
 func (s *DatabaseRequest) GetName() string {
@@ -150,3 +188,9 @@ func (s *DatabaseSource) UpdateState(dbStatus *grpcServerApi.DbStatusResponse) b
 func (s *DatabaseSource) Synced() bool {
 	return s.State == s.ExpectState
 }
+
+// IsScheduled reports whether the source waits to be handled at NextScheduleAt,
+// e.g. the delayed deletion or the retry after a failure.
+func (s *DatabaseSource) IsScheduled() bool {
+	return s.State == SourceStateScheduling && !s.NextScheduleAt.IsZero()
+}
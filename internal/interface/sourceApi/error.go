@@ -0,0 +1,10 @@
+package sourceApi
+
+import "errors"
+
+var (
+	ErrSourceNotFound error = errors.New("database source not found")
+	ErrNotWebSource   error = errors.New("database source is not created from web")
+	ErrSourceDeleting error = errors.New("database source is being deleted")
+	ErrExpiresAtPast  error = errors.New("expires_at is not in the future")
+)
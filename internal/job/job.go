@@ -17,6 +17,9 @@ type Job interface {
 
 	IsFailed() bool
 	IsDone() bool
+
+	// Cancel the pending tasks and interrupt the running tasks
+	Cancel(reason string)
 }
 
 type BaseJob struct {
@@ -91,6 +94,28 @@ func (j *BaseJob) ReadyToRun() []Task {
 	return tasks
 }
 
+// Cancel cancels the pending tasks immediately,
+// the running tasks are interrupted and cancelled once they stop.
+func (j *BaseJob) Cancel(reason string) {
+	for _, task := range j.Tasks {
+		if task.IsPending() {
+			task.Cancel(reason)
+		} else if task.IsRunning() {
+			task.Interrupt(reason)
+		}
+	}
+}
+
+// CancelJob asks the job handler to cancel the job
+type CancelJob struct {
+	ID     uuid.UUID
+	Reason string
+}
+
+func (c *CancelJob) GetName() string {
+	return "Cancel " + c.ID.String()
+}
+
 type InitJobProvider interface {
 	RecoverJobs() (jobs []Job, err error)
 }
@@ -40,6 +40,8 @@ func (h *JobHandler) PostInit(getter server.GlobalGetter) error {
 
 func (h *JobHandler) Handle(msg server.NamedElement) error {
 	switch msg := msg.(type) {
+	case *CancelJob:
+		return h.cancelJob(msg)
 	case Job:
 		return h.addJob(msg)
 	case Task:
@@ -143,6 +145,32 @@ func (h *JobHandler) processCancellingTask(task Task) error {
 	return nil
 }
 
+func (h *JobHandler) cancelJob(cancel *CancelJob) error {
+	h.jobsLock.Lock()
+	defer h.jobsLock.Unlock()
+
+	job, ok := h.jobs[cancel.ID]
+	if !ok {
+		err := ErrJobNotFound
+		log.Warn().Err(err).
+			Str("JobID", cancel.ID.String()).
+			Msg("Can not cancel the job")
+		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	}
+
+	log.Info().
+		Str("JobName", job.GetName()).
+		Str("JobID", cancel.ID.String()).
+		Str("Reason", cancel.Reason).
+		Msg("Cancel the job")
+
+	job.Cancel(cancel.Reason)
+	if job.IsDone() {
+		h.onJobDone(job)
+	}
+	return nil
+}
+
 func (h *JobHandler) onJobDone(job Job) {
 	log.Debug().
 		Str("JobName", job.GetName()).
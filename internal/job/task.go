@@ -23,6 +23,8 @@ type SubTask interface {
 	JobID() uuid.UUID
 
 	Cancel(reason string)
+	// Interrupt stops the running task, it is cancelled once it stops
+	Interrupt(reason string)
 }
 
 type TaskDependency interface {
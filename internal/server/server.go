@@ -6,6 +6,7 @@ import (
 	"github.com/a-light-win/pg-helper/internal/handler/grpc_server"
 	"github.com/a-light-win/pg-helper/internal/handler/web_server"
 	"github.com/a-light-win/pg-helper/internal/source"
+	"github.com/a-light-win/pg-helper/internal/store"
 	"github.com/a-light-win/pg-helper/pkg/server"
 )
 
@@ -16,6 +17,8 @@ type Server struct {
 
 func New(config *config.ServerConfig) *Server {
 	signalServer := server.NewSignalServer()
+	// The store must be placed before the servers that load the state from it
+	store := store.NewStore(&config.Db, signalServer.QuitCtx)
 	grpcServer := grpc_server.NewGrpcServer(&config.Grpc, signalServer.QuitCtx)
 	webServer := web_server.NewWebServer(&config.Web)
 	cronServer := server.NewCronServer()
@@ -32,6 +35,7 @@ func New(config *config.ServerConfig) *Server {
 			Name: "PG Helper Server",
 			Servers: []server.Server{
 				signalServer,
+				store,
 				cronServer,
 				grpcServer,
 				sourceConsumer,
@@ -9,6 +9,7 @@ import (
 	"github.com/a-light-win/pg-helper/internal/constants"
 	"github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
 	"github.com/a-light-win/pg-helper/internal/interface/sourceApi"
+	"github.com/a-light-win/pg-helper/internal/store"
 	"github.com/a-light-win/pg-helper/pkg/server"
 	"github.com/a-light-win/pg-helper/pkg/utils/logger"
 	"github.com/a-light-win/pg-helper/pkg/validate"
@@ -29,6 +30,7 @@ type BaseSourceHandler struct {
 	cronProducer   server.Producer
 	sourceProducer server.Producer
 	dbManager      grpcServerApi.DbManager
+	store          *store.Store
 
 	validator *validator.Validate
 }
@@ -50,15 +52,71 @@ func (h *BaseSourceHandler) PostInit(getter server.GlobalGetter) error {
 	h.cronProducer = getter.Get(constants.ServerKeyCronProducer).(server.Producer)
 	h.sourceProducer = getter.Get(constants.ServerKeySourceProducer).(server.Producer)
 	h.dbManager = getter.Get(constants.ServerKeyDbManager).(grpcServerApi.DbManager)
+	h.store = getter.Get(constants.ServerKeyStore).(*store.Store)
+
+	if err := h.loadDatabaseSources(); err != nil {
+		return err
+	}
 
 	h.dbManager.SubscribeDbStatus(h.OnDbStatusChanged)
 	h.dbManager.SubscribeInstanceStatus(h.OnInstanceStatusChanged)
 	return nil
 }
 
+// loadDatabaseSources restores the database sources saved before the restart.
+// The sources that are scheduled keep their schedules, which are armed again by OnStart,
+// the other sources that are not synced are pending until their instances are online.
+func (h *BaseSourceHandler) loadDatabaseSources() error {
+	sources, err := h.store.LoadSources()
+	if err != nil {
+		return err
+	}
+
+	h.databasesMutex.Lock()
+	defer h.databasesMutex.Unlock()
+
+	for _, source := range sources {
+		if !source.Synced() && !source.IsScheduled() {
+			source.State = sourceApi.SourceStatePending
+		}
+		h.Databases[source.Name] = source
+	}
+	log.Debug().Int("Count", len(sources)).Msg("Load database sources")
+	return nil
+}
+
+// OnStart arms the schedules of the database sources loaded from the store,
+// e.g. the expiration of the ephemeral databases, the delayed deletions and the retries.
+func (h *BaseSourceHandler) OnStart() {
+	h.databasesMutex.Lock()
+	defer h.databasesMutex.Unlock()
+
+	for _, source := range h.Databases {
+		if source.IsScheduled() {
+			log.Debug().Str("DbName", source.Name).
+				Time("NextScheduleAt", source.NextScheduleAt).
+				Msg("Schedule the database source again")
+			h.scheduleAt(source.Name, source.NextScheduleAt)
+		}
+		// The expired source is rescheduled to idle, the schedule above is outdated then
+		if !source.ExpireAt.IsZero() && source.ExpectState == sourceApi.SourceStateReady {
+			h.scheduleExpire(source)
+		}
+	}
+}
+
+func (h *BaseSourceHandler) saveDatabaseSource(source *sourceApi.DatabaseSource) {
+	// The dropped source is deleted from the store by RemoveDatabaseSource
+	if source.State == sourceApi.SourceStateDropped {
+		return
+	}
+	h.store.SaveSource(source)
+}
+
 func (h *BaseSourceHandler) Handle(msg server.NamedElement) error {
 	source := msg.(*sourceApi.DatabaseSource)
 	source.LastScheduledAt = time.Now()
+	defer h.saveDatabaseSource(source)
 
 	if h.syncDatabaseSource(source) {
 		return nil
@@ -67,10 +125,7 @@ func (h *BaseSourceHandler) Handle(msg server.NamedElement) error {
 	source.State = sourceApi.SourceStateProcessing
 
 	if source.ExpectState == sourceApi.SourceStateIdle {
-		// TODO: mark database as idle and drop it later (a month or more later?)
-		// we do not remove it immediately because we want to keep the data for a while
-		// in case we need to rollback.
-		return nil
+		return h.idleDatabase(source)
 	}
 
 	dbPassword, err := source.PasswordContent()
@@ -86,59 +141,142 @@ func (h *BaseSourceHandler) Handle(msg server.NamedElement) error {
 			InstanceName: source.InstanceName,
 			Name:         source.Name,
 		},
-		Owner:       source.Owner,
-		Password:    dbPassword,
-		MigrateFrom: source.MigrateFrom,
-		BackupPath:  source.BackupPath,
-		Reason:      fmt.Sprintf("Create database %s from source %s", source.Name, source.Type),
+		Owner:           source.Owner,
+		Password:        dbPassword,
+		MigrateFrom:     source.MigrateFrom,
+		BackupPath:      source.BackupPath,
+		MigrateStrategy: source.MigrateStrategy,
+		Reason:          fmt.Sprintf("Create database %s from source %s", source.Name, source.Type),
 	}
 
 	if err := h.dbManager.CreateDb(request); err != nil {
 		log.Warn().Err(err).
 			Str("DbName", source.Name).
 			Msg("Failed to create database")
+		return h.onRequestFailed(source, err)
+	}
 
-		source.LastErrorMsg = err.Error()
-		source.UpdatedAt = time.Now()
-		if err == grpcServerApi.ErrInstanceOffline {
-			source.State = sourceApi.SourceStatePending
-		} else {
-			source.State = sourceApi.SourceStateFailed
-			h.retryNextTime(source)
-		}
-		return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+	return nil
+}
+
+// idleDatabase marks the database as idle, and the agent drops it after it is expired.
+// We do not drop it immediately because we want to keep the data for a while
+// in case we need to rollback, unless the ephemeral database is expired.
+func (h *BaseSourceHandler) idleDatabase(source *sourceApi.DatabaseSource) error {
+	request := &grpcServerApi.IdleDbRequest{
+		Name:         source.Name,
+		InstanceName: source.InstanceName,
+		Reason:       fmt.Sprintf("Database source %s is removed", source.Type),
+	}
+	if !source.ExpireAt.IsZero() && !source.ExpireAt.After(time.Now()) {
+		request.Reason = fmt.Sprintf("Database source %s is expired", source.Type)
+		request.ExpireAt = source.ExpireAt
+	}
+
+	if err := h.dbManager.IdleDb(request); err != nil {
+		log.Warn().Err(err).
+			Str("DbName", source.Name).
+			Msg("Failed to idle database")
+		return h.onRequestFailed(source, err)
 	}
 
 	return nil
 }
 
+func (h *BaseSourceHandler) onRequestFailed(source *sourceApi.DatabaseSource, err error) error {
+	source.LastErrorMsg = err.Error()
+	source.UpdatedAt = time.Now()
+	if err == grpcServerApi.ErrInstanceOffline {
+		source.State = sourceApi.SourceStatePending
+	} else {
+		source.State = sourceApi.SourceStateFailed
+		h.retryNextTime(source)
+	}
+	return logger.NewAlreadyLoggedError(err, zerolog.WarnLevel)
+}
+
 func (h *BaseSourceHandler) AddDatabaseSource(source *sourceApi.DatabaseSource) error {
 	if err := h.validator.Struct(source); err != nil {
 		return err
 	}
 
+	now := time.Now()
+	source.AddedAt = now
+
 	h.databasesMutex.Lock()
 	defer h.databasesMutex.Unlock()
-	if oldSource, ok := h.Databases[source.Name]; ok {
+	oldSource, ok := h.Databases[source.Name]
+	if ok {
 		if !oldSource.IsConfigChanged(source.DatabaseRequest) {
 			log.Debug().Str("source", source.Name).Msg("Source not changed, skip")
 			return nil
 		}
 		log.Debug().Str("Name", source.Name).Msg("source changed")
+		if !oldSource.AddedAt.IsZero() {
+			source.AddedAt = oldSource.AddedAt
+		}
 	} else {
 		log.Debug().Str("Name", source.Name).Msg("source added")
 	}
 
+	// The unchanged expires_at is accepted even if it has passed,
+	// e.g. the file source of an expired database is changed.
+	if source.ExpiresAt != nil && !source.ExpiresAt.After(now) &&
+		(!ok || oldSource.ExpiresAt == nil || !oldSource.ExpiresAt.Equal(*source.ExpiresAt)) {
+		return sourceApi.ErrExpiresAtPast
+	}
+
 	if source.ExpectState == "" {
 		source.ExpectState = sourceApi.SourceStateReady
 	}
+	source.ExpireAt = source.ExpireTime(source.AddedAt)
 	h.Databases[source.Name] = source
+	h.saveDatabaseSource(source)
 
-	go h.sourceProducer.Send(source)
+	if !source.ExpireAt.IsZero() {
+		h.scheduleExpire(source)
+	}
+	if source.ExpectState == sourceApi.SourceStateReady {
+		go h.sourceProducer.Send(source)
+	}
 
 	return nil
 }
 
+// scheduleExpire marks the ephemeral database source idle once it is expired
+func (h *BaseSourceHandler) scheduleExpire(source *sourceApi.DatabaseSource) {
+	if !source.ExpireAt.After(time.Now()) {
+		log.Info().Str("DbName", source.Name).
+			Time("ExpireAt", source.ExpireAt).
+			Msg("Database source is already expired")
+		h.expireDatabaseSource(source)
+		return
+	}
+
+	name := source.Name
+	h.cronProducer.Send(&server.CronElement{
+		TriggerAt: source.ExpireAt,
+		HandleFunc: func(triggerAt time.Time) {
+			h.databasesMutex.Lock()
+			defer h.databasesMutex.Unlock()
+			if source, ok := h.Databases[name]; ok && source.ExpireAt.Equal(triggerAt) {
+				log.Info().Str("DbName", name).
+					Time("ExpireAt", source.ExpireAt).
+					Msg("Database source is expired")
+				h.expireDatabaseSource(source)
+			}
+		},
+	})
+}
+
+func (h *BaseSourceHandler) expireDatabaseSource(source *sourceApi.DatabaseSource) {
+	source.ExpectState = sourceApi.SourceStateIdle
+	source.State = sourceApi.SourceStateScheduling
+	source.NextScheduleAt = source.ExpireAt
+	h.saveDatabaseSource(source)
+	go h.sourceProducer.Send(source)
+}
+
 func (h *BaseSourceHandler) MarkDatabaseSourceIdle(name string) error {
 	h.databasesMutex.Lock()
 	defer h.databasesMutex.Unlock()
@@ -148,6 +286,7 @@ func (h *BaseSourceHandler) MarkDatabaseSourceIdle(name string) error {
 			source.ExpectState = sourceApi.SourceStateIdle
 			source.State = sourceApi.SourceStateScheduling
 			source.NextScheduleAt = time.Now().Add(h.Config.DeleyDelete)
+			h.saveDatabaseSource(source)
 			h.cronProducer.Send(&server.CronElement{
 				TriggerAt: source.NextScheduleAt,
 				HandleFunc: func(triggerAt time.Time) {
@@ -181,14 +320,14 @@ func (h *BaseSourceHandler) OnDbStatusChanged(dbStatus *grpcServerApi.DbStatusRe
 
 func (h *BaseSourceHandler) updateDbStatus(source *sourceApi.DatabaseSource, dbStatus *grpcServerApi.DbStatusResponse) {
 	if source.UpdateState(dbStatus) {
-		if source.State == sourceApi.SourceStateFailed {
-			h.retryNextTime(source)
-			return
-		}
 		if source.State == sourceApi.SourceStateDropped {
 			go h.RemoveDatabaseSource(source)
 			return
 		}
+		if source.State == sourceApi.SourceStateFailed {
+			h.retryNextTime(source)
+		}
+		h.saveDatabaseSource(source)
 	}
 }
 
@@ -198,6 +337,7 @@ func (h *BaseSourceHandler) RemoveDatabaseSource(source *sourceApi.DatabaseSourc
 
 	if source.State == sourceApi.SourceStateDropped {
 		delete(h.Databases, source.Name)
+		h.store.DeleteSource(source.Name)
 	}
 }
 
@@ -227,14 +367,20 @@ func (h *BaseSourceHandler) retryNextTime(source *sourceApi.DatabaseSource) {
 		Interface("NextScheduleAt", source.NextScheduleAt).
 		Msg("Retry next time")
 
+	h.scheduleAt(source.Name, source.NextScheduleAt)
+}
+
+// scheduleAt handles the source again at the time,
+// unless the source is rescheduled or removed before that.
+func (h *BaseSourceHandler) scheduleAt(name string, at time.Time) {
 	h.cronProducer.Send(&server.CronElement{
-		TriggerAt: source.NextScheduleAt,
+		TriggerAt: at,
 		HandleFunc: func(triggerAt time.Time) {
 			h.databasesMutex.Lock()
 			defer h.databasesMutex.Unlock()
-			if source_, ok := h.Databases[source.Name]; ok {
-				if source_.NextScheduleAt.Equal(triggerAt) {
-					go h.sourceProducer.Send(source_)
+			if source, ok := h.Databases[name]; ok {
+				if source.NextScheduleAt.Equal(triggerAt) {
+					go h.sourceProducer.Send(source)
 				}
 			}
 		},
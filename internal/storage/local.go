@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// LocalStorage saves the backups in a local directory,
+// the directory should be shared between the agents to migrate databases.
+type LocalStorage struct {
+	Root string
+}
+
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{Root: root}
+}
+
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.Root, filepath.FromSlash(key))
+}
+
+func (s *LocalStorage) Save(ctx context.Context, localPath string, key string) error {
+	target := s.path(key)
+	if filepath.Clean(localPath) == target {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return err
+	}
+	return os.Rename(localPath, target)
+}
+
+func (s *LocalStorage) Fetch(ctx context.Context, key string) (string, func(), error) {
+	if _, err := s.Stat(ctx, key); err != nil {
+		return "", nil, err
+	}
+	return s.path(key), func() {}, nil
+}
+
+func (s *LocalStorage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	info, err := os.Stat(s.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotExist
+		}
+		return nil, err
+	}
+	return &ObjectInfo{
+		Key:     key,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}, nil
+}
+
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	entries, err := os.ReadDir(s.path(prefix))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var objects []ObjectInfo
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		objects = append(objects, ObjectInfo{
+			Key:     filepath.ToSlash(filepath.Join(prefix, entry.Name())),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			IsDir:   entry.IsDir(),
+		})
+	}
+	return objects, nil
+}
+
+func (s *LocalStorage) Remove(ctx context.Context, key string) error {
+	return os.RemoveAll(s.path(key))
+}
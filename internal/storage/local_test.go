@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stage writes a backup file or directory under dir, the directory backup ends with "/"
+func stage(t *testing.T, dir string, name string) string {
+	localPath := filepath.Join(dir, name)
+	if name[len(name)-1] == '/' {
+		require.NoError(t, os.MkdirAll(localPath, 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(localPath, "toc.dat"), []byte("toc"), 0640))
+		return filepath.Clean(localPath)
+	}
+	require.NoError(t, os.MkdirAll(filepath.Dir(localPath), 0750))
+	require.NoError(t, os.WriteFile(localPath, []byte("backup"), 0640))
+	return localPath
+}
+
+func TestLocalStorage_SaveFetch(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name  string
+		local string
+		key   string
+		isDir bool
+	}{
+		{name: "file", local: "staged.sql", key: "pg-16/app/2024-07-10_01:00:00.sql"},
+		{name: "directory", local: "staged.dir/", key: "pg-16/app/2024-07-10_01:00:00.dir", isDir: true},
+		{name: "already in place", local: "pg-16/app/2024-07-11_01:00:00.sql", key: "pg-16/app/2024-07-11_01:00:00.sql"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			s := NewLocalStorage(root)
+
+			localPath := stage(t, root, tc.local)
+			require.NoError(t, s.Save(ctx, localPath, tc.key))
+
+			info, err := s.Stat(ctx, tc.key)
+			require.NoError(t, err)
+			assert.Equal(t, tc.key, info.Key)
+			assert.Equal(t, tc.isDir, info.IsDir)
+
+			fetched, release, err := s.Fetch(ctx, tc.key)
+			require.NoError(t, err)
+			defer release()
+			assert.Equal(t, filepath.Join(root, filepath.FromSlash(tc.key)), fetched)
+			_, err = os.Stat(fetched)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLocalStorage_FetchNotExist(t *testing.T) {
+	s := NewLocalStorage(t.TempDir())
+
+	_, _, err := s.Fetch(context.Background(), "pg-16/app/missing.sql")
+	assert.ErrorIs(t, err, ErrNotExist)
+
+	_, err = s.Stat(context.Background(), "pg-16/app/missing.sql")
+	assert.ErrorIs(t, err, ErrNotExist)
+}
+
+func TestLocalStorage_List(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	s := NewLocalStorage(root)
+
+	stage(t, root, "pg-16/app/2024-07-10_01:00:00.sql")
+	stage(t, root, "pg-16/app/2024-07-11_01:00:00.dir/")
+	stage(t, root, "pg-16/other/2024-07-10_01:00:00.sql")
+
+	testCases := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{name: "backups of a database", prefix: "pg-16/app", want: []string{"pg-16/app/2024-07-10_01:00:00.sql", "pg-16/app/2024-07-11_01:00:00.dir"}},
+		{name: "databases of an instance", prefix: "pg-16", want: []string{"pg-16/app", "pg-16/other"}},
+		{name: "missing prefix", prefix: "pg-15", want: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objects, err := s.List(ctx, tc.prefix)
+			require.NoError(t, err)
+
+			var keys []string
+			for _, object := range objects {
+				keys = append(keys, object.Key)
+			}
+			sort.Strings(keys)
+			assert.Equal(t, tc.want, keys)
+		})
+	}
+}
+
+func TestLocalStorage_Remove(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name  string
+		local string
+		key   string
+	}{
+		{name: "file", local: "pg-16/app/2024-07-10_01:00:00.sql", key: "pg-16/app/2024-07-10_01:00:00.sql"},
+		{name: "directory", local: "pg-16/app/2024-07-11_01:00:00.dir/", key: "pg-16/app/2024-07-11_01:00:00.dir"},
+		{name: "not exist", key: "pg-16/app/missing.sql"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			s := NewLocalStorage(root)
+			if tc.local != "" {
+				stage(t, root, tc.local)
+			}
+
+			require.NoError(t, s.Remove(ctx, tc.key))
+			_, err := s.Stat(ctx, tc.key)
+			assert.ErrorIs(t, err, ErrNotExist)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	config "github.com/a-light-win/pg-helper/internal/config/agent"
+)
+
+// SaveManifest saves the manifest beside the backup,
+// the manifest is staged in localRoot before saving.
+func SaveManifest(ctx context.Context, s Storage, localRoot string, manifest *config.BackupManifest) error {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	key := config.BackupManifestPath(manifest.BackupPath)
+	localPath := filepath.Join(localRoot, filepath.FromSlash(key)+".tmp")
+	if err := os.MkdirAll(filepath.Dir(localPath), 0750); err != nil {
+		return err
+	}
+	if err := os.WriteFile(localPath, content, 0640); err != nil {
+		return err
+	}
+
+	if err := s.Save(ctx, localPath, key); err != nil {
+		os.Remove(localPath)
+		return err
+	}
+	return nil
+}
+
+// LoadManifest loads the manifest of the backup,
+// ErrNotExist is returned if the backup has no manifest.
+func LoadManifest(ctx context.Context, s Storage, backupPath string) (*config.BackupManifest, error) {
+	localPath, release, err := s.Fetch(ctx, config.BackupManifestPath(backupPath))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	content, err := os.ReadFile(localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &config.BackupManifest{}
+	if err := json.Unmarshal(content, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	config "github.com/a-light-win/pg-helper/internal/config/agent"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage saves the backups in a S3 compatible storage, e.g. MinIO.
+//
+// The backups are staged in the local root and removed after uploaded,
+// and are downloaded to the local root when they are fetched.
+type S3Storage struct {
+	Config    *config.S3StorageConfig
+	LocalRoot string
+
+	client *minio.Client
+}
+
+func NewS3Storage(s3Config *config.S3StorageConfig, localRoot string) (*S3Storage, error) {
+	lookup := minio.BucketLookupAuto
+	if s3Config.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(s3Config.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(s3Config.AccessKey, s3Config.SecretKey, ""),
+		Secure:       s3Config.UseSsl,
+		Region:       s3Config.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Storage{
+		Config:    s3Config,
+		LocalRoot: localRoot,
+		client:    client,
+	}, nil
+}
+
+func (s *S3Storage) Save(ctx context.Context, localPath string, key string) error {
+	err := filepath.WalkDir(localPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(localPath, p)
+		if err != nil {
+			return err
+		}
+		objectKey := s.Config.ObjectKey(key)
+		if rel != "." {
+			objectKey = path.Join(objectKey, filepath.ToSlash(rel))
+		}
+
+		_, err = s.client.FPutObject(ctx, s.Config.Bucket, objectKey, p, minio.PutObjectOptions{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(localPath)
+}
+
+func (s *S3Storage) Fetch(ctx context.Context, key string) (string, func(), error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp(s.LocalRoot, ".fetch-")
+	if err != nil {
+		return "", nil, err
+	}
+	release := func() { os.RemoveAll(tmpDir) }
+	localPath := filepath.Join(tmpDir, path.Base(key))
+
+	if !info.IsDir {
+		err = s.client.FGetObject(ctx, s.Config.Bucket, s.Config.ObjectKey(key), localPath, minio.GetObjectOptions{})
+	} else {
+		err = s.fetchDir(ctx, key, localPath)
+	}
+	if err != nil {
+		release()
+		return "", nil, err
+	}
+	return localPath, release, nil
+}
+
+func (s *S3Storage) fetchDir(ctx context.Context, key string, localPath string) error {
+	dirKey := s.Config.ObjectKey(key) + "/"
+	for object := range s.client.ListObjects(ctx, s.Config.Bucket, minio.ListObjectsOptions{
+		Prefix:    dirKey,
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			return object.Err
+		}
+		target := filepath.Join(localPath, filepath.FromSlash(strings.TrimPrefix(object.Key, dirKey)))
+		if err := s.client.FGetObject(ctx, s.Config.Bucket, object.Key, target, minio.GetObjectOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	object, err := s.client.StatObject(ctx, s.Config.Bucket, s.Config.ObjectKey(key), minio.StatObjectOptions{})
+	if err == nil {
+		return &ObjectInfo{
+			Key:     key,
+			Size:    object.Size,
+			ModTime: object.LastModified,
+		}, nil
+	}
+	if minio.ToErrorResponse(err).Code != "NoSuchKey" {
+		return nil, err
+	}
+
+	// The backup may be a directory
+	for object := range s.client.ListObjects(ctx, s.Config.Bucket, minio.ListObjectsOptions{
+		Prefix:  s.Config.ObjectKey(key) + "/",
+		MaxKeys: 1,
+	}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		return &ObjectInfo{Key: key, ModTime: object.LastModified, IsDir: true}, nil
+	}
+	return nil, ErrNotExist
+}
+
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	for object := range s.client.ListObjects(ctx, s.Config.Bucket, minio.ListObjectsOptions{
+		Prefix: s.Config.ObjectKey(prefix) + "/",
+	}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+
+		name := path.Base(object.Key)
+		objects = append(objects, ObjectInfo{
+			Key:     path.Join(prefix, name),
+			Size:    object.Size,
+			ModTime: object.LastModified,
+			IsDir:   strings.HasSuffix(object.Key, "/"),
+		})
+	}
+	return objects, nil
+}
+
+func (s *S3Storage) Remove(ctx context.Context, key string) error {
+	for object := range s.client.ListObjects(ctx, s.Config.Bucket, minio.ListObjectsOptions{
+		Prefix:    s.Config.ObjectKey(key) + "/",
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			return object.Err
+		}
+		if err := s.client.RemoveObject(ctx, s.Config.Bucket, object.Key, minio.RemoveObjectOptions{}); err != nil {
+			return err
+		}
+	}
+	return s.client.RemoveObject(ctx, s.Config.Bucket, s.Config.ObjectKey(key), minio.RemoveObjectOptions{})
+}
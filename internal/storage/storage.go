@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	config "github.com/a-light-win/pg-helper/internal/config/agent"
+)
+
+var ErrNotExist = errors.New("backup not exist")
+
+type ObjectInfo struct {
+	// The key of the backup, in the format of DbTaskData.BackupPath
+	Key     string
+	Size    int64
+	ModTime time.Time
+	// The backup is a directory, e.g. a backup in pg_dump directory format
+	IsDir bool
+}
+
+// Storage saves the backups.
+//
+// The key of a backup is its path relative to DbConfig.BackupRootPath,
+// e.g. `pg-16/mydb/2024-07-10_01:00:00.sql`,
+// so the same key can be used by the agents on different hosts.
+type Storage interface {
+	// Save moves the local file or directory to the storage as key.
+	Save(ctx context.Context, localPath string, key string) error
+	// Fetch makes the backup available as a local path,
+	// release must be called when the local path is no longer used.
+	Fetch(ctx context.Context, key string) (localPath string, release func(), err error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// List the backups directly under the prefix.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	Remove(ctx context.Context, key string) error
+}
+
+// New creates the storage by the config,
+// the backups are staged in localRoot before saving to the storage.
+func New(storageConfig *config.StorageConfig, localRoot string) (Storage, error) {
+	switch storageConfig.Type {
+	case "", config.StorageTypeLocal:
+		return NewLocalStorage(localRoot), nil
+	case config.StorageTypeS3:
+		return NewS3Storage(&storageConfig.S3, localRoot)
+	default:
+		return nil, fmt.Errorf("invalid storage type %s", storageConfig.Type)
+	}
+}
@@ -0,0 +1,30 @@
+package store
+
+import (
+	"github.com/rs/zerolog/log"
+)
+
+// SaveInstance registers the pg instance, or refreshes the time it is online
+func (s *Store) SaveInstance(name string, pgVersion int32) error {
+	err := s.Query(func(q *Queries) error {
+		return q.UpsertInstance(s.ConnCtx, UpsertInstanceParams{Name: name, PgVersion: pgVersion})
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("DbInstance", name).Msg("Failed to save the pg instance")
+	}
+	return err
+}
+
+// LoadInstances returns the registered pg instances, or nothing if the store is disabled
+func (s *Store) LoadInstances() ([]Instance, error) {
+	var instances []Instance
+	err := s.Query(func(q *Queries) (err error) {
+		instances, err = q.ListInstances(s.ConnCtx)
+		return err
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load the pg instances")
+		return nil, err
+	}
+	return instances, nil
+}
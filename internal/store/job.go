@@ -0,0 +1,114 @@
+package store
+
+import (
+	"time"
+
+	api "github.com/a-light-win/pg-helper/internal/interface/grpcServerApi"
+	"github.com/a-light-win/pg-helper/pkg/proto"
+	"github.com/a-light-win/pg-helper/pkg/utils"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// The tasks of the job reported by the agent, it is saved as jsonb
+type JobTasks []*api.TaskResponse
+
+// SaveJob records the job sent to the pg instance in the job history
+func (s *Store) SaveJob(instanceName string, job *proto.DbJob) error {
+	params := CreateJobParams{
+		ID:           utils.StringToUuid(job.JobId),
+		InstanceName: instanceName,
+		DbName:       job.DbName(),
+		Type:         job.JobType(),
+		Reason:       job.Reason(),
+	}
+
+	err := s.Query(func(q *Queries) error {
+		return q.CreateJob(s.ConnCtx, params)
+	})
+	if err != nil {
+		log.Warn().Err(err).
+			Str("JobId", job.JobId).
+			Str("DbInstance", instanceName).
+			Msg("Failed to save the job")
+	}
+	return err
+}
+
+// SaveJobStatus saves the tasks reported by the agent,
+// the report older than reportedAt is ignored.
+func (s *Store) SaveJobStatus(job *api.JobResponse, reportedAt time.Time) error {
+	params := UpsertJobStatusParams{
+		ID:           utils.StringToUuid(job.JobId),
+		InstanceName: job.InstanceName,
+		DbName:       job.DbName,
+		Reason:       job.Reason,
+		Status:       job.Status,
+		Tasks:        job.Tasks,
+		ReportedAt:   toTimestamp(reportedAt),
+	}
+
+	err := s.Query(func(q *Queries) error {
+		return q.UpsertJobStatus(s.ConnCtx, params)
+	})
+	if err != nil {
+		log.Warn().Err(err).
+			Str("JobId", job.JobId).
+			Str("DbInstance", job.InstanceName).
+			Msg("Failed to save the job status")
+	}
+	return err
+}
+
+func (s *Store) GetJob(jobId string) (*api.JobResponse, error) {
+	var job Job
+	err := s.Query(func(q *Queries) (err error) {
+		job, err = q.GetJob(s.ConnCtx, utils.StringToUuid(jobId))
+		return err
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, api.ErrJobNotFound
+		}
+		log.Warn().Err(err).Str("JobId", jobId).Msg("Failed to load the job")
+		return nil, err
+	}
+	return job.ToJobResponse(), nil
+}
+
+// ListJobs returns the job history of the database, newest first
+func (s *Store) ListJobs(dbName string, limit int32) ([]*api.JobResponse, error) {
+	var jobs []Job
+	err := s.Query(func(q *Queries) (err error) {
+		jobs, err = q.ListJobsByDbName(s.ConnCtx, ListJobsByDbNameParams{DbName: dbName, MaxCount: limit})
+		return err
+	})
+	if err != nil && err != pgx.ErrNoRows {
+		log.Warn().Err(err).Str("DbName", dbName).Msg("Failed to load the job history")
+		return nil, err
+	}
+
+	result := make([]*api.JobResponse, 0, len(jobs))
+	for i := range jobs {
+		result = append(result, jobs[i].ToJobResponse())
+	}
+	return result, nil
+}
+
+func (j *Job) ToJobResponse() *api.JobResponse {
+	tasks := j.Tasks
+	if tasks == nil {
+		tasks = JobTasks{}
+	}
+	return &api.JobResponse{
+		JobId:        j.ID.String(),
+		InstanceName: j.InstanceName,
+		DbName:       j.DbName,
+		Type:         j.Type,
+		Reason:       j.Reason,
+		Status:       j.Status,
+		CreatedAt:    fromTimestamp(j.CreatedAt),
+		UpdatedAt:    fromTimestamp(j.UpdatedAt),
+		Tasks:        tasks,
+	}
+}
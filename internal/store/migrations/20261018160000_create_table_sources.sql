@@ -0,0 +1,38 @@
+-- +goose NO TRANSACTION
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sources (
+    name VARCHAR(255) PRIMARY KEY,
+    type VARCHAR(32) NOT NULL,
+
+    owner VARCHAR(255) NOT NULL,
+    password_file TEXT NOT NULL DEFAULT '',
+    -- Only the web sources keep the password here,
+    -- the file sources read it from the password file.
+    password TEXT NOT NULL DEFAULT '',
+
+    instance_name VARCHAR(255) NOT NULL,
+    migrate_from VARCHAR(255) NOT NULL DEFAULT '',
+    backup_path TEXT NOT NULL DEFAULT '',
+    migrate_strategy VARCHAR(32) NOT NULL DEFAULT '',
+    ttl VARCHAR(64) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP,
+
+    expect_state VARCHAR(32) NOT NULL,
+    state VARCHAR(32) NOT NULL,
+    retry_delay int4 NOT NULL DEFAULT 0,
+    retry_times int4 NOT NULL DEFAULT 0,
+    expire_at TIMESTAMP,
+    last_error_msg TEXT NOT NULL DEFAULT '',
+    next_schedule_at TIMESTAMP,
+
+    created_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now())
+  );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS sources;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose NO TRANSACTION
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS instances (
+    name VARCHAR(255) PRIMARY KEY,
+    pg_version int4 NOT NULL,
+
+    created_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now()),
+    last_online_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now())
+  );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS instances;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose NO TRANSACTION
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS jobs (
+    id UUID PRIMARY KEY,
+    instance_name VARCHAR(255) NOT NULL,
+    -- Empty if the job is not for a database, e.g. base_backup
+    db_name VARCHAR(255) NOT NULL DEFAULT '',
+    -- The job type in the DbJob oneof, e.g. create_database
+    type VARCHAR(64) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+
+    created_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now())
+  );
+
+CREATE INDEX jobs_db_name_idx ON jobs (db_name, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS jobs_db_name_idx;
+DROP TABLE IF EXISTS jobs;
+-- +goose StatementEnd
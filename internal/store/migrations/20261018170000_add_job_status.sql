@@ -0,0 +1,23 @@
+-- +goose NO TRANSACTION
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE jobs
+  -- One of pending, running, completed, failed, cancelled
+  ADD COLUMN IF NOT EXISTS status VARCHAR(32) NOT NULL DEFAULT 'pending',
+  -- The tasks reported by the agent
+  ADD COLUMN IF NOT EXISTS tasks JSONB NOT NULL DEFAULT '[]'::JSONB,
+  -- The latest time that the tasks are updated on the agent,
+  -- it is null until the agent reports the job status.
+  ADD COLUMN IF NOT EXISTS reported_at TIMESTAMP,
+  ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now());
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE jobs
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS reported_at,
+  DROP COLUMN IF EXISTS tasks,
+  DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
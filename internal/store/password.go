@@ -0,0 +1,97 @@
+package store
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// The prefix of the encrypted passwords,
+// the passwords saved before the encryption is enabled have no prefix.
+const encryptedPasswordPrefix = "age:"
+
+// passwordCipher encrypts the passwords of the web database sources by age,
+// the passwords are kept in plain text if no identity is provided.
+type passwordCipher struct {
+	identities []age.Identity
+	recipient  age.Recipient
+}
+
+func newPasswordCipher(identityFile string) (*passwordCipher, error) {
+	if identityFile == "" {
+		return &passwordCipher{}, nil
+	}
+
+	f, err := os.Open(identityFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, err
+	}
+	x25519, ok := identities[0].(*age.X25519Identity)
+	if !ok {
+		return nil, errors.New("the first identity of the password identity file is not a X25519 identity")
+	}
+
+	return &passwordCipher{
+		identities: identities,
+		recipient:  x25519.Recipient(),
+	}, nil
+}
+
+func (c *passwordCipher) Enabled() bool {
+	return c != nil && c.recipient != nil
+}
+
+func (c *passwordCipher) Encrypt(password string) (string, error) {
+	if !c.Enabled() || password == "" {
+		return password, nil
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, c.recipient)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.WriteString(w, password); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return encryptedPasswordPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// Decrypt returns the saved password as it is if it is not encrypted,
+// so the passwords saved in plain text are still readable after the encryption is enabled.
+func (c *passwordCipher) Decrypt(saved string) (string, error) {
+	if !strings.HasPrefix(saved, encryptedPasswordPrefix) {
+		return saved, nil
+	}
+	if !c.Enabled() {
+		return "", errors.New("no password identity file to decrypt the password")
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(saved, encryptedPasswordPrefix))
+	if err != nil {
+		return "", err
+	}
+	r, err := age.Decrypt(bytes.NewReader(encrypted), c.identities...)
+	if err != nil {
+		return "", err
+	}
+	password, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
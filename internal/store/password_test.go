@@ -0,0 +1,69 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeIdentityFile(t *testing.T, identities ...*age.X25519Identity) string {
+	var content strings.Builder
+	for _, identity := range identities {
+		content.WriteString(identity.String() + "\n")
+	}
+	path := filepath.Join(t.TempDir(), "identity.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content.String()), 0600))
+	return path
+}
+
+func TestPasswordCipher(t *testing.T) {
+	oldIdentity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	newIdentity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	oldCipher, err := newPasswordCipher(writeIdentityFile(t, oldIdentity))
+	require.NoError(t, err)
+	savedByOldKey, err := oldCipher.Encrypt("old-secret")
+	require.NoError(t, err)
+
+	// The new key is the first one after rotating
+	cipher, err := newPasswordCipher(writeIdentityFile(t, newIdentity, oldIdentity))
+	require.NoError(t, err)
+
+	saved, err := cipher.Encrypt("secret-password")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(saved, encryptedPasswordPrefix))
+	assert.NotContains(t, saved, "secret-password")
+
+	password, err := cipher.Decrypt(saved)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-password", password)
+
+	password, err = cipher.Decrypt(savedByOldKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "old-secret", password)
+
+	// The password saved before the encryption is enabled
+	password, err = cipher.Decrypt("plain-password")
+	assert.NoError(t, err)
+	assert.Equal(t, "plain-password", password)
+}
+
+func TestPasswordCipher_Disabled(t *testing.T) {
+	cipher, err := newPasswordCipher("")
+	require.NoError(t, err)
+	assert.False(t, cipher.Enabled())
+
+	saved, err := cipher.Encrypt("secret-password")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-password", saved)
+
+	_, err = cipher.Decrypt(encryptedPasswordPrefix + "c2VjcmV0")
+	assert.Error(t, err)
+}
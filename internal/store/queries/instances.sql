@@ -0,0 +1,8 @@
+-- name: UpsertInstance :exec
+INSERT INTO instances (name, pg_version) VALUES (@name, @pg_version)
+ON CONFLICT (name) DO UPDATE SET
+  pg_version = EXCLUDED.pg_version,
+  last_online_at = timezone('utc', now());
+
+-- name: ListInstances :many
+SELECT * FROM instances ORDER BY name;
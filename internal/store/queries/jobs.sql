@@ -0,0 +1,24 @@
+-- name: CreateJob :exec
+INSERT INTO jobs (id, instance_name, db_name, type, reason)
+VALUES (@id, @instance_name, @db_name, @type, @reason)
+ON CONFLICT (id) DO NOTHING;
+
+-- The job started by the agent itself is added when its status is reported,
+-- the stale report is ignored.
+-- name: UpsertJobStatus :exec
+INSERT INTO jobs (id, instance_name, db_name, type, reason, status, tasks, reported_at)
+VALUES (@id, @instance_name, @db_name, '', @reason, @status, @tasks, @reported_at)
+ON CONFLICT (id) DO UPDATE SET
+  status = EXCLUDED.status,
+  tasks = EXCLUDED.tasks,
+  reported_at = EXCLUDED.reported_at,
+  updated_at = timezone('utc', now())
+WHERE jobs.reported_at IS NULL OR jobs.reported_at <= EXCLUDED.reported_at;
+
+-- name: GetJob :one
+SELECT * FROM jobs WHERE id = @id;
+
+-- name: ListJobsByDbName :many
+SELECT * FROM jobs WHERE db_name = @db_name
+ORDER BY created_at DESC
+LIMIT @max_count;
@@ -0,0 +1,32 @@
+-- name: UpsertSource :exec
+INSERT INTO sources (name, type, owner, password_file, password,
+  instance_name, migrate_from, backup_path, migrate_strategy, ttl, expires_at,
+  expect_state, state, retry_delay, retry_times, expire_at, last_error_msg, next_schedule_at)
+VALUES (@name, @type, @owner, @password_file, @password,
+  @instance_name, @migrate_from, @backup_path, @migrate_strategy, @ttl, @expires_at,
+  @expect_state, @state, @retry_delay, @retry_times, @expire_at, @last_error_msg, @next_schedule_at)
+ON CONFLICT (name) DO UPDATE SET
+  type = EXCLUDED.type,
+  owner = EXCLUDED.owner,
+  password_file = EXCLUDED.password_file,
+  password = EXCLUDED.password,
+  instance_name = EXCLUDED.instance_name,
+  migrate_from = EXCLUDED.migrate_from,
+  backup_path = EXCLUDED.backup_path,
+  migrate_strategy = EXCLUDED.migrate_strategy,
+  ttl = EXCLUDED.ttl,
+  expires_at = EXCLUDED.expires_at,
+  expect_state = EXCLUDED.expect_state,
+  state = EXCLUDED.state,
+  retry_delay = EXCLUDED.retry_delay,
+  retry_times = EXCLUDED.retry_times,
+  expire_at = EXCLUDED.expire_at,
+  last_error_msg = EXCLUDED.last_error_msg,
+  next_schedule_at = EXCLUDED.next_schedule_at,
+  updated_at = timezone('utc', now());
+
+-- name: DeleteSource :exec
+DELETE FROM sources WHERE name = @name;
+
+-- name: ListSources :many
+SELECT * FROM sources ORDER BY name;
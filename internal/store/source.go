@@ -0,0 +1,115 @@
+package store
+
+import (
+	"github.com/a-light-win/pg-helper/internal/interface/sourceApi"
+	"github.com/rs/zerolog/log"
+)
+
+func (s *Store) SaveSource(source *sourceApi.DatabaseSource) error {
+	params := UpsertSourceParams{
+		Name:            source.Name,
+		Type:            string(source.Type),
+		Owner:           source.Owner,
+		PasswordFile:    source.PasswordFile,
+		InstanceName:    source.InstanceName,
+		MigrateFrom:     source.MigrateFrom,
+		BackupPath:      source.BackupPath,
+		MigrateStrategy: source.MigrateStrategy,
+		Ttl:             source.Ttl,
+		ExpectState:     string(source.ExpectState),
+		State:           string(source.State),
+		RetryDelay:      int32(source.RetryDelay),
+		RetryTimes:      int32(source.RetryTimes),
+		ExpireAt:        toTimestamp(source.ExpireAt),
+		LastErrorMsg:    source.LastErrorMsg,
+		NextScheduleAt:  toTimestamp(source.NextScheduleAt),
+	}
+	if source.ExpiresAt != nil {
+		params.ExpiresAt = toTimestamp(*source.ExpiresAt)
+	}
+	// The file sources read the password from the password file again
+	if source.Type == sourceApi.WebSource {
+		password, err := s.passwords.Encrypt(source.Password)
+		if err != nil {
+			log.Warn().Err(err).Str("DbName", source.Name).Msg("Failed to encrypt the password of the database source")
+			return err
+		}
+		params.Password = password
+	}
+
+	err := s.Query(func(q *Queries) error {
+		return q.UpsertSource(s.ConnCtx, params)
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("DbName", source.Name).Msg("Failed to save the database source")
+	}
+	return err
+}
+
+func (s *Store) DeleteSource(name string) error {
+	err := s.Query(func(q *Queries) error {
+		return q.DeleteSource(s.ConnCtx, name)
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("DbName", name).Msg("Failed to delete the database source")
+	}
+	return err
+}
+
+// LoadSources returns the saved database sources, or nothing if the store is disabled
+func (s *Store) LoadSources() ([]*sourceApi.DatabaseSource, error) {
+	var sources []Source
+	err := s.Query(func(q *Queries) (err error) {
+		sources, err = q.ListSources(s.ConnCtx)
+		return err
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load the database sources")
+		return nil, err
+	}
+
+	result := make([]*sourceApi.DatabaseSource, 0, len(sources))
+	for i := range sources {
+		source := sources[i].ToDatabaseSource()
+		if source.Password, err = s.passwords.Decrypt(source.Password); err != nil {
+			log.Error().Err(err).Str("DbName", source.Name).Msg("Failed to decrypt the password of the database source")
+			return nil, err
+		}
+		result = append(result, source)
+	}
+	return result, nil
+}
+
+func (s *Source) ToDatabaseSource() *sourceApi.DatabaseSource {
+	request := &sourceApi.DatabaseRequest{
+		Name:            s.Name,
+		Owner:           s.Owner,
+		PasswordFile:    s.PasswordFile,
+		Password:        s.Password,
+		InstanceName:    s.InstanceName,
+		MigrateFrom:     s.MigrateFrom,
+		BackupPath:      s.BackupPath,
+		MigrateStrategy: s.MigrateStrategy,
+		Ttl:             s.Ttl,
+	}
+	if s.ExpiresAt.Valid {
+		expiresAt := fromTimestamp(s.ExpiresAt)
+		request.ExpiresAt = &expiresAt
+	}
+
+	return &sourceApi.DatabaseSource{
+		DatabaseRequest: request,
+		Type:            sourceApi.SourceType(s.Type),
+		DatabaseSourceStatus: sourceApi.DatabaseSourceStatus{
+			ExpectState:    sourceApi.SourceState(s.ExpectState),
+			State:          sourceApi.SourceState(s.State),
+			UpdatedAt:      fromTimestamp(s.UpdatedAt),
+			AddedAt:        fromTimestamp(s.CreatedAt),
+			RetryDelay:     int(s.RetryDelay),
+			RetryTimes:     int(s.RetryTimes),
+			ExpireAt:       fromTimestamp(s.ExpireAt),
+			LastErrorMsg:   s.LastErrorMsg,
+			NextScheduleAt: fromTimestamp(s.NextScheduleAt),
+		},
+	}
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	config "github.com/a-light-win/pg-helper/internal/config/server"
+	"github.com/a-light-win/pg-helper/internal/constants"
+	"github.com/a-light-win/pg-helper/pkg/server"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/rs/zerolog/log"
+)
+
+// Store keeps the server state in its own database,
+// so the state survives the restarts of the server.
+//
+// The store is disabled if DbConfig.Url is empty,
+// all the writes are ignored and nothing is loaded then.
+type Store struct {
+	DbConfig *config.DbConfig
+	DbPool   *pgxpool.Pool
+
+	ConnCtx context.Context
+	Cancel  context.CancelFunc
+
+	QuitCtx context.Context
+
+	passwords *passwordCipher
+}
+
+type QueryFunc func(*Queries) error
+
+func NewStore(config *config.DbConfig, quitCtx context.Context) *Store {
+	connCtx, cancel := context.WithCancel(context.Background())
+	return &Store{
+		DbConfig: config,
+		ConnCtx:  connCtx,
+		Cancel:   cancel,
+		QuitCtx:  quitCtx,
+	}
+}
+
+func (s *Store) Enabled() bool {
+	return s != nil && s.DbPool != nil
+}
+
+func (s *Store) Init(setter server.GlobalSetter) error {
+	setter.Set(constants.ServerKeyStore, s)
+
+	if s.DbConfig.Url == "" {
+		log.Warn().Msg("No database to keep the server state, it is lost on restart")
+		return nil
+	}
+
+	passwords, err := newPasswordCipher(s.DbConfig.PasswordIdentityFile)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load the password identity file")
+		return err
+	}
+	if !passwords.Enabled() {
+		log.Warn().Msg("No password identity file, the passwords of the web database sources are saved in plain text")
+	}
+	s.passwords = passwords
+
+	poolConfig, err := s.DbConfig.NewPoolConfig()
+	if err != nil {
+		return err
+	}
+	s.DbPool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create a pool")
+		return err
+	}
+	return nil
+}
+
+// PostInit migrates the database before the other servers load the state in their PostInit
+func (s *Store) PostInit(getter server.GlobalGetter) error {
+	if !s.Enabled() {
+		return nil
+	}
+	return s.MigrateDB()
+}
+
+func (s *Store) Run() {
+}
+
+func (s *Store) Shutdown(ctx context.Context) {
+	s.Cancel()
+	if s.DbPool != nil {
+		s.DbPool.Close()
+	}
+}
+
+func (s *Store) Query(queryFunc QueryFunc) error {
+	if !s.Enabled() {
+		return nil
+	}
+
+	conn, err := s.DbPool.Acquire(s.ConnCtx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to acquire connection")
+		return err
+	}
+	defer conn.Release()
+
+	return queryFunc(New(conn))
+}
+
+func (s *Store) MigrateDB() error {
+	log.Log().Msg("Start to migrate the server database")
+
+	db_ := stdlib.OpenDBFromPool(s.DbPool)
+	defer db_.Close()
+
+	// Ensure the database connection is ready.
+	for {
+		if err := db_.Ping(); err != nil {
+			log.Warn().Err(err).Msg("Ping the server database failed")
+
+			select {
+			case <-s.QuitCtx.Done():
+				log.Log().Err(s.QuitCtx.Err()).Msg("Receive quit signal when ping the server database")
+				return s.QuitCtx.Err()
+			case <-time.After(5 * time.Second):
+				continue
+			}
+		}
+		break
+	}
+
+	if err := MigrateUp(db_); err != nil {
+		log.Error().Err(err).Msg("Migrate the server database failed")
+		return err
+	}
+
+	log.Log().Msg("Migrate the server database success")
+	return nil
+}
@@ -0,0 +1,23 @@
+package store
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// The timestamps are saved in utc without time zone,
+// the zero time is saved as null.
+func toTimestamp(t time.Time) pgtype.Timestamp {
+	if t.IsZero() {
+		return pgtype.Timestamp{}
+	}
+	return pgtype.Timestamp{Time: t.UTC(), Valid: true}
+}
+
+func fromTimestamp(ts pgtype.Timestamp) time.Time {
+	if !ts.Valid {
+		return time.Time{}
+	}
+	return ts.Time.Local()
+}
@@ -0,0 +1,53 @@
+package proto
+
+import "google.golang.org/protobuf/reflect/protoreflect"
+
+// JobType returns the name of the job in the oneof, e.g. create_database
+func (j *DbJob) JobType() string {
+	if fd := j.jobField(); fd != nil {
+		return string(fd.Name())
+	}
+	return ""
+}
+
+// DbName returns the name of the database the job works on,
+// it is empty if the job is not for a database, e.g. base_backup
+func (j *DbJob) DbName() string {
+	if named, ok := j.jobMessage().(interface{ GetName() string }); ok {
+		return named.GetName()
+	}
+	return ""
+}
+
+func (j *DbJob) Reason() string {
+	if reasoned, ok := j.jobMessage().(interface{ GetReason() string }); ok {
+		return reasoned.GetReason()
+	}
+	return ""
+}
+
+func (j *DbJob) jobField() protoreflect.FieldDescriptor {
+	if j == nil {
+		return nil
+	}
+	m := j.ProtoReflect()
+	return m.WhichOneof(m.Descriptor().Oneofs().ByName("job"))
+}
+
+func (j *DbJob) jobMessage() interface{} {
+	fd := j.jobField()
+	if fd == nil {
+		return nil
+	}
+	return j.ProtoReflect().Get(fd).Message().Interface()
+}
+
+// GetName makes the job status a server.NamedElement
+func (j *JobStatus) GetName() string {
+	return j.GetJobId()
+}
+
+// GetName makes the recovery window a server.NamedElement
+func (w *RecoveryWindow) GetName() string {
+	return w.GetInstanceName()
+}
@@ -10,12 +10,25 @@ func (d *Database) IsNotExist() bool {
 	return d == nil || d.Stage == DbStage_None
 }
 
+func (d *Database) IsDropped() bool {
+	return d != nil &&
+		d.Stage == DbStage_DropDatabase &&
+		d.Status == DbStatus_Done
+}
+
 func (d *Database) IsReadyToUse() bool {
 	return d != nil &&
 		d.Stage == DbStage_ReadyToUse &&
 		d.Status == DbStatus_Done
 }
 
+// The database is in use and can be restored in place,
+// or the previous in-place restore is failed and can be retried.
+func (d *Database) CanRestoreInPlace() bool {
+	return d.IsReadyToUse() ||
+		(d != nil && d.Stage == DbStage_RestoreDatabase && d.Status == DbStatus_Failed)
+}
+
 func (d *Database) IsFailed() bool {
 	return d != nil && d.Status == DbStatus_Failed
 }
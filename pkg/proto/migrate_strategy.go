@@ -0,0 +1,10 @@
+package proto
+
+const (
+	// Dump the database on the old pg instance and restore it on the new one,
+	// the application must stop writing during the migration.
+	MigrateStrategyDump = "dump"
+	// Replicate the database from the old pg instance by logical replication,
+	// the application only stops writing during a short cutover.
+	MigrateStrategyLogical = "logical"
+)
@@ -43,6 +43,10 @@ func (c *BaseConsumer[T]) Run() {
 	sem := make(chan struct{}, c.MaxConcurrency)
 	defer close(sem)
 
+	if starter, ok := c.Handler.(Starter); ok {
+		go starter.OnStart()
+	}
+
 	for {
 		element, ok := <-c.Elements
 		if !ok {
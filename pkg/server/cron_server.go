@@ -30,8 +30,9 @@ type CronServer struct {
 
 func NewCronServer() *CronServer {
 	cronServer := &CronServer{
-		Elements:            make([]*CronElement, 0),
-		firstElementChanged: make(chan struct{}),
+		Elements: make([]*CronElement, 0),
+		// It is buffered, so the elements can be sent before the server is running
+		firstElementChanged: make(chan struct{}, 1),
 		exited:              make(chan struct{}),
 	}
 	return cronServer
@@ -72,13 +73,13 @@ func (s *CronServer) Send(msg NamedElement) {
 
 	if len(s.Elements) == 0 {
 		s.Elements = append(s.Elements, element)
-		s.firstElementChanged <- struct{}{}
+		s.notifyFirstElementChanged()
 		return
 	}
 
 	if element.TriggerAt.Before(s.Elements[0].TriggerAt) {
 		s.Elements = append([]*CronElement{element}, s.Elements...)
-		s.firstElementChanged <- struct{}{}
+		s.notifyFirstElementChanged()
 		return
 	}
 
@@ -91,6 +92,15 @@ func (s *CronServer) Send(msg NamedElement) {
 	s.Elements = append(s.Elements, element)
 }
 
+// notifyFirstElementChanged wakes up the server to wait for the new first element,
+// the pending notification is enough if the server is not woken up yet.
+func (s *CronServer) notifyFirstElementChanged() {
+	select {
+	case s.firstElementChanged <- struct{}{}:
+	default:
+	}
+}
+
 func (s *CronServer) Producer() Producer {
 	return s
 }
@@ -51,6 +51,12 @@ type InitializableHandler interface {
 	Initialize
 }
 
+// Starter is implemented by the handlers that need to do something
+// once the servers are running, e.g. to send the elements loaded in PostInit to other servers.
+type Starter interface {
+	OnStart()
+}
+
 type FileChangedHandler interface {
 	InitializableHandler
 
@@ -24,6 +24,9 @@ func RegisterCustomValidations(validatorEngine *validator.Validate) {
 	if err := validatorEngine.RegisterValidation("iname", isValidName); err != nil {
 		log.Fatal().Err(err).Msg("Failed to register valid_name validator")
 	}
+	if err := validatorEngine.RegisterValidation("ttl", validateTtl); err != nil {
+		log.Fatal().Err(err).Msg("Failed to register ttl validator")
+	}
 }
 
 func New() *validator.Validate {
@@ -0,0 +1,16 @@
+package validate
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validateTtl checks the field is a positive duration, e.g. 72h
+func validateTtl(field validator.FieldLevel) bool {
+	if field.Field().String() == "" {
+		return true
+	}
+	ttl, err := time.ParseDuration(field.Field().String())
+	return err == nil && ttl > 0
+}
@@ -0,0 +1,42 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type TtlStruct struct {
+	Ttl string `validate:"ttl"`
+}
+
+func TestValidateTtl(t *testing.T) {
+	v := validator.New()
+	v.RegisterValidation("ttl", validateTtl)
+
+	testCases := []struct {
+		name string
+		ttl  string
+		want bool
+	}{
+		{name: "empty", ttl: "", want: true},
+		{name: "hours", ttl: "72h", want: true},
+		{name: "mixed", ttl: "1h30m", want: true},
+		{name: "zero", ttl: "0s", want: false},
+		{name: "negative", ttl: "-1h", want: false},
+		{name: "days", ttl: "3d", want: false},
+		{name: "no unit", ttl: "60", want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := v.Struct(TtlStruct{Ttl: tc.ttl})
+			if tc.want && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if !tc.want && err == nil {
+				t.Errorf("expected error, got nil")
+			}
+		})
+	}
+}